@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	ErrInvalidPublicKey = errors.New("public key must be 32 bytes")
+	ErrInvalidSecretKey = errors.New("secret key must be 64 bytes and match the public key")
+	ErrNoSecretKey      = errors.New("key pair has no secret key to sign with")
+)
+
+// KeyPair is an Ed25519 key pair used to sign and verify a feed
+// A key pair without a secret key can only be used to verify
+type KeyPair struct {
+	PublicKey ed25519.PublicKey
+	SecretKey ed25519.PrivateKey
+}
+
+// GenerateKeyPair generates a new random key pair
+func GenerateKeyPair() (KeyPair, error) {
+	public, secret, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{PublicKey: public, SecretKey: secret}, nil
+}
+
+// NewKeyPair constructs a key pair from existing keys
+// The secret key may be nil, resulting in a verify only key pair
+func NewKeyPair(publicKey, secretKey []byte) (KeyPair, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return KeyPair{}, ErrInvalidPublicKey
+	}
+	if secretKey == nil {
+		return KeyPair{PublicKey: publicKey}, nil
+	}
+
+	// the secret key embeds the public key in its last 32 bytes
+	if len(secretKey) != ed25519.PrivateKeySize || !bytes.Equal(secretKey[32:], publicKey) {
+		return KeyPair{}, ErrInvalidSecretKey
+	}
+
+	return KeyPair{PublicKey: publicKey, SecretKey: secretKey}, nil
+}
+
+// CanSign checks if the key pair holds a secret key
+func (kp KeyPair) CanSign() bool {
+	return kp.SecretKey != nil
+}
+
+// Sign returns the signature of the message using the secret key
+func (kp KeyPair) Sign(message []byte) ([]byte, error) {
+	if !kp.CanSign() {
+		return nil, ErrNoSecretKey
+	}
+
+	return ed25519.Sign(kp.SecretKey, message), nil
+}
+
+// Verify checks the signature of the message against the public key
+func (kp KeyPair) Verify(message, signature []byte) bool {
+	return Verify(kp.PublicKey, message, signature)
+}
+
+// Verify checks the signature of the message against the provided public key
+func Verify(publicKey, message, signature []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	return ed25519.Verify(publicKey, message, signature)
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateKeyPair(t *testing.T) {
+	t.Parallel()
+
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.Len(t, kp.PublicKey, 32)
+	assert.Len(t, kp.SecretKey, 64)
+	assert.True(t, kp.CanSign())
+
+	other, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.NotEqual(t, kp.PublicKey, other.PublicKey)
+}
+
+func Test_NewKeyPair(t *testing.T) {
+	t.Parallel()
+
+	generated, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	kp, err := NewKeyPair(generated.PublicKey, generated.SecretKey)
+	assert.NoError(t, err)
+	assert.Equal(t, generated, kp)
+
+	kp, err = NewKeyPair(generated.PublicKey, nil)
+	assert.NoError(t, err)
+	assert.False(t, kp.CanSign())
+
+	_, err = NewKeyPair(generated.PublicKey[:31], nil)
+	assert.Equal(t, ErrInvalidPublicKey, err)
+
+	other, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	_, err = NewKeyPair(generated.PublicKey, other.SecretKey)
+	assert.Equal(t, ErrInvalidSecretKey, err)
+}
+
+func Test_SignAndVerify(t *testing.T) {
+	t.Parallel()
+
+	kp, err := GenerateKeyPair()
+	assert.NoError(t, err)
+
+	message := []byte("hello, world!")
+	signature, err := kp.Sign(message)
+	assert.NoError(t, err)
+	assert.Len(t, signature, 64)
+
+	assert.True(t, kp.Verify(message, signature))
+	assert.True(t, Verify(kp.PublicKey, message, signature))
+	assert.False(t, Verify(kp.PublicKey, []byte("goodbye, world!"), signature))
+	assert.False(t, Verify(kp.PublicKey[:16], message, signature))
+
+	other, err := GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.False(t, Verify(other.PublicKey, message, signature))
+
+	readOnly, err := NewKeyPair(kp.PublicKey, nil)
+	assert.NoError(t, err)
+	_, err = readOnly.Sign(message)
+	assert.Equal(t, ErrNoSecretKey, err)
+	assert.True(t, readOnly.Verify(message, signature))
+}
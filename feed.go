@@ -0,0 +1,570 @@
+package hypercore
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/indexed"
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/sleep"
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+var (
+	ErrClosed         = errors.New("feed is closed")
+	ErrOutOfBounds    = errors.New("block index out of bounds")
+	ErrNotWritable    = errors.New("feed has no secret key to append with")
+	ErrSeekBounds     = errors.New("byte offset out of bounds")
+	ErrKeyMismatch    = errors.New("provided key pair does not match the key stored by the feed")
+	ErrUnsignedLength = errors.New("length was never signed by the feed")
+)
+
+// merkleStream is the subset of the merkle stream used by the feed
+type merkleStream interface {
+	AppendBatch(blocks [][]byte) error
+	Truncate(length uint64) error
+	Roots() []merkle.Node
+	Blocks() uint64
+	TreeHash() []byte
+}
+
+// Options configures the construction of a feed
+type Options struct {
+	Hasher  merkle.NodeHasher // hashing implementation producing 32 byte hashes; defaults to BLAKE2b256
+	Storage storage.Provider  // opens the storage of each feed file; defaults to in-memory storage
+	KeyPair *crypto.KeyPair   // keys of the feed; defaults to the stored keys, or a newly generated pair
+}
+
+// Feed is an append-only log of blocks, hashed into a merkle tree
+type Feed struct {
+	hasher     merkle.NodeHasher
+	keyPair    crypto.KeyPair
+	stream     merkleStream       // merkle tree built over the appended blocks
+	tree       *indexed.Tree      // flat tree index of the nodes held by the feed
+	bitfield   *bitfield.Bitfield // one bit per block held by the feed
+	data       storage.Storage    // the block data, stored back to back
+	nodes      *sleep.Tree        // hash and size of every node in the merkle tree, by flat tree index
+	store      *sleep.TreeStore   // node store of the merkle stream, writing through to the tree file
+	signatures *sleep.Signatures  // signature of the roots after each append
+	bitfields  *sleep.Bitfield    // persisted pages of the block and node bitfields
+	fork       uint64             // number of times the feed has been truncated
+	forkStore  storage.Storage    // persisted fork counter
+	closed     bool
+	mu         *sync.RWMutex
+}
+
+// NewFeed constructs a feed, resuming from any feed already held in storage
+func NewFeed(opts Options) (*Feed, error) {
+	if opts.Hasher == nil {
+		opts.Hasher = merkle.BLAKE2b256{}
+	}
+	// the tree file only holds 32 byte hashes, so no node of another hasher could be stored
+	if len(opts.Hasher.HashRoots(nil)) != merkle.HashSize {
+		return nil, merkle.ErrInvalidHashSize
+	}
+	if opts.Storage == nil {
+		opts.Storage = storage.MemoryProvider()
+	}
+
+	keyPair, err := loadKeyPair(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := opts.Storage("data")
+	if err != nil {
+		return nil, err
+	}
+
+	treeStorage, err := opts.Storage("tree")
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := sleep.OpenTree(treeStorage, opts.Hasher.Algorithm())
+	if err != nil {
+		return nil, err
+	}
+
+	signatureStorage, err := opts.Storage("signatures")
+	if err != nil {
+		return nil, err
+	}
+	signatures, err := sleep.OpenSignatures(signatureStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	bitfieldStorage, err := opts.Storage("bitfield")
+	if err != nil {
+		return nil, err
+	}
+	bitfields, err := sleep.OpenBitfield(bitfieldStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	forkStore, err := opts.Storage("fork")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		hasher:     opts.Hasher,
+		keyPair:    keyPair,
+		tree:       indexed.NewTree(bitfield.NewBitfield(0)),
+		bitfield:   bitfield.NewBitfield(0),
+		data:       data,
+		nodes:      nodes,
+		store:      sleep.NewTreeStore(nodes),
+		signatures: signatures,
+		bitfields:  bitfields,
+		forkStore:  forkStore,
+		mu:         &sync.RWMutex{},
+	}
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// load restores the bitfields, fork and merkle stream of the feed from storage
+func (f *Feed) load() error {
+	// the index bitfield of the SLEEP bitfield file is not used by this implementation
+	if err := f.bitfields.Load(f.bitfield, f.tree.Bitfield(), bitfield.NewBitfield(0)); err != nil {
+		return err
+	}
+	// the tree index is restored as stored rather than rebuilt from the nodes, so is checked for corruption
+	if err := f.tree.Validate(); err != nil {
+		return err
+	}
+
+	fork, err := readFork(f.forkStore)
+	if err != nil {
+		return err
+	}
+	f.fork = fork
+
+	// the last leaf always has the highest index of any node written to the tree file
+	entries, err := f.nodes.Len()
+	if err != nil {
+		return err
+	}
+
+	return f.resumeStream((entries + 1) / 2)
+}
+
+// resumeStream reconstructs the merkle stream from the stored roots of the tree with the provided length
+func (f *Feed) resumeStream(length uint64) error {
+	rootIndices, err := flattree.FullRoots(length * 2)
+	if err != nil {
+		return err
+	}
+
+	roots := []merkle.Node{}
+	for _, index := range rootIndices {
+		root, err := f.node(index)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, root)
+	}
+
+	stream, err := merkle.ResumeStream(f.hasher, roots, f.store)
+	if err != nil {
+		return err
+	}
+	f.stream = stream
+
+	return nil
+}
+
+// loadKeyPair returns the key pair provided in the options, the key pair held in storage,
+// or a newly generated key pair, in that order of preference
+// Provided and generated keys are written to storage
+// A provided key pair must have the same public key as the one held in storage, if any
+func loadKeyPair(opts Options) (keyPair crypto.KeyPair, err error) {
+	publicStorage, err := opts.Storage("key")
+	if err != nil {
+		return
+	}
+	defer publicStorage.Close()
+
+	secretStorage, err := opts.Storage("secret_key")
+	if err != nil {
+		return
+	}
+	defer secretStorage.Close()
+
+	var stat storage.Stat
+	if stat, err = publicStorage.Stat(); err != nil {
+		return
+	}
+
+	if opts.KeyPair != nil {
+		keyPair = *opts.KeyPair
+		if stat.Size > 0 {
+			var stored crypto.KeyPair
+			if stored, err = readKeyPair(publicStorage, secretStorage); err != nil {
+				return
+			}
+			if !bytes.Equal(stored.PublicKey, keyPair.PublicKey) {
+				return crypto.KeyPair{}, ErrKeyMismatch
+			}
+		}
+	} else {
+		if stat.Size > 0 {
+			return readKeyPair(publicStorage, secretStorage)
+		}
+		if keyPair, err = crypto.GenerateKeyPair(); err != nil {
+			return
+		}
+	}
+
+	if err = sleep.WritePublicKey(publicStorage, keyPair.PublicKey); err != nil {
+		return
+	}
+	if keyPair.CanSign() {
+		err = sleep.WriteSecretKey(secretStorage, keyPair.SecretKey)
+	}
+
+	return
+}
+
+func readKeyPair(publicStorage, secretStorage storage.Storage) (crypto.KeyPair, error) {
+	publicKey, err := sleep.ReadPublicKey(publicStorage)
+	if err != nil {
+		return crypto.KeyPair{}, err
+	}
+
+	stat, err := secretStorage.Stat()
+	if err != nil {
+		return crypto.KeyPair{}, err
+	}
+	if stat.Size == 0 {
+		return crypto.NewKeyPair(publicKey, nil)
+	}
+
+	secretKey, err := sleep.ReadSecretKey(secretStorage)
+	if err != nil {
+		return crypto.KeyPair{}, err
+	}
+
+	return crypto.NewKeyPair(publicKey, secretKey)
+}
+
+// Append adds the provided blocks to the end of the feed, signing the resulting roots
+// Returns the index of the first appended block
+func (f *Feed) Append(blocks ...[]byte) (seq uint64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if !f.keyPair.CanSign() {
+		return 0, ErrNotWritable
+	}
+
+	seq = f.stream.Blocks()
+	if len(blocks) == 0 {
+		return seq, nil
+	}
+
+	// the stream writes the new nodes to the tree file through its store, before any data is written,
+	// so that blocks the tree rejects are not left in the data file
+	offset := f.byteLength()
+	if err = f.stream.AppendBatch(blocks); err != nil {
+		return seq, err
+	}
+
+	for _, block := range blocks {
+		if err = f.data.Write(offset, block); err != nil {
+			return seq, err
+		}
+		offset += uint64(len(block))
+	}
+
+	for index := seq; index < f.stream.Blocks(); index++ {
+		// the tree index sets the parents of the leaf as their subtrees fill up
+		f.tree.Set(index * 2)
+		f.bitfield.SetBit(int(index), true)
+	}
+
+	// setting the new leaves may also set ancestors which precede them, in earlier pages
+	if err = f.storeBitfields(seq, f.lowestSetAncestor(seq*2)); err != nil {
+		return seq, err
+	}
+
+	// the signatures file holds signatures of the bare tree hash, as written by hypercore v9
+	signature, err := f.keyPair.Sign(f.stream.TreeHash())
+	if err != nil {
+		return seq, err
+	}
+
+	return seq, f.signatures.Put(f.stream.Blocks()-1, signature)
+}
+
+// Writer returns an io.Writer appending its input to the feed in blocks of blockSize bytes
+// The writer must be flushed or closed to append the final partial block
+func (f *Feed) Writer(blockSize int) (*merkle.Writer, error) {
+	return merkle.NewWriter(f.appender(), blockSize)
+}
+
+// CDCWriter returns an io.Writer appending its input to the feed in content-defined blocks
+// The writer must be flushed or closed to append the final partial block
+func (f *Feed) CDCWriter(opts merkle.CDCOptions) (*merkle.Writer, error) {
+	return merkle.NewCDCWriter(f.appender(), opts)
+}
+
+// appender adapts Append into a merkle.BlockAppender
+func (f *Feed) appender() merkle.BlockAppender {
+	return merkle.AppendFunc(func(blocks [][]byte) error {
+		_, err := f.Append(blocks...)
+		return err
+	})
+}
+
+// storeBitfields writes the bitfield pages changed from the provided block and flat tree index onwards
+func (f *Feed) storeBitfields(fromBlock, fromNode uint64) error {
+	firstPage := fromBlock / (sleep.DataPageSize * 8)
+	if nodePage := fromNode / (sleep.TreePageSize * 8); nodePage < firstPage {
+		firstPage = nodePage
+	}
+	lastPage := (f.bitfield.ByteLength() - 1) / sleep.DataPageSize
+	if treePage := (f.tree.Bitfield().ByteLength() - 1) / sleep.TreePageSize; treePage > lastPage {
+		lastPage = treePage
+	}
+
+	for page := firstPage; page <= lastPage; page++ {
+		if err := f.bitfields.PutPage(page, f.bitfield, f.tree.Bitfield(), bitfield.NewBitfield(0)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lowestSetAncestor returns the lowest flat tree index of the node and its set ancestors
+// A parent is only set once both of its children are, so the set ancestors form an unbroken chain
+func (f *Feed) lowestSetAncestor(index uint64) uint64 {
+	lowest := index
+	for parent := flattree.Parent(index); f.tree.Get(parent); parent = flattree.Parent(parent) {
+		if parent < lowest {
+			lowest = parent
+		}
+	}
+	return lowest
+}
+
+// Get returns the data of the block at the provided index
+func (f *Feed) Get(index uint64) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if index >= f.stream.Blocks() || !f.bitfield.GetBit(index) {
+		return nil, ErrOutOfBounds
+	}
+
+	offset, err := f.dataOffset(index)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := f.node(index * 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.data.Read(offset, leaf.Size())
+}
+
+// Seek finds the block containing the provided byte offset into the feed
+// Returns the index of the block, and the offset of the byte relative to the start of that block
+func (f *Feed) Seek(byteOffset uint64) (index, relativeOffset uint64, err error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return 0, 0, ErrClosed
+	}
+
+	for _, root := range f.stream.Roots() {
+		if byteOffset >= root.Size() {
+			byteOffset -= root.Size()
+			continue
+		}
+
+		// descend into the subtree spanning the offset, until reaching its leaf
+		next := root.Index()
+		for {
+			left, right, hasChildren := flattree.Children(next)
+			if !hasChildren {
+				return flattree.LeftSpan(next) / 2, byteOffset, nil
+			}
+
+			leftNode, err := f.node(left)
+			if err != nil {
+				return 0, 0, err
+			}
+			if byteOffset < leftNode.Size() {
+				next = left
+			} else {
+				byteOffset -= leftNode.Size()
+				next = right
+			}
+		}
+	}
+
+	return 0, 0, ErrSeekBounds
+}
+
+// dataOffset returns the byte offset of a block within the data storage
+// This is the total size of the roots of the tree formed by all preceding blocks
+func (f *Feed) dataOffset(index uint64) (offset uint64, err error) {
+	roots, err := flattree.FullRoots(index * 2)
+	if err != nil {
+		return
+	}
+
+	for _, root := range roots {
+		node, err := f.node(root)
+		if err != nil {
+			return 0, err
+		}
+		offset += node.Size()
+	}
+
+	return
+}
+
+// node returns the stored node at the provided flat tree index
+func (f *Feed) node(index uint64) (merkle.Node, error) {
+	entry, err := f.nodes.Get(index)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Node(index), nil
+}
+
+// byteLength returns the total size of all blocks, from the sizes of the roots
+func (f *Feed) byteLength() (length uint64) {
+	for _, root := range f.stream.Roots() {
+		length += root.Size()
+	}
+	return
+}
+
+// Signature returns the signature of the feed's state when it had a length of index+1
+// Only lengths at which an append or truncation completed are signed
+func (f *Feed) Signature(index uint64) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if index >= f.stream.Blocks() {
+		return nil, ErrOutOfBounds
+	}
+
+	signature, err := f.signatures.Get(index)
+	if err != nil {
+		return nil, err
+	}
+	// the entries of lengths within an append batch are left blank
+	if bytes.Equal(signature, make([]byte, sleep.SignatureSize)) {
+		return nil, ErrUnsignedLength
+	}
+	return signature, nil
+}
+
+// VerifyRoots checks the signature of the tree formed by a set of roots against the public key of the feed
+func (f *Feed) VerifyRoots(roots []merkle.Node, signature []byte) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.keyPair.Verify(f.hasher.HashRoots(roots), signature)
+}
+
+// PublicKey returns the public key of the feed
+func (f *Feed) PublicKey() []byte {
+	return f.keyPair.PublicKey
+}
+
+// Writable checks if the feed holds the secret key required to append
+func (f *Feed) Writable() bool {
+	return f.keyPair.CanSign()
+}
+
+// Has checks if the block at the provided index is held by the feed
+func (f *Feed) Has(index uint64) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.bitfield.GetBit(index)
+}
+
+// Len returns the number of blocks in the feed
+func (f *Feed) Len() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.stream.Blocks()
+}
+
+// ContiguousLength returns the number of blocks held contiguously from the start of the feed
+func (f *Feed) ContiguousLength() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.tree.ContiguousLength()
+}
+
+// ByteLength returns the total size of all blocks in the feed, in bytes
+func (f *Feed) ByteLength() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.byteLength()
+}
+
+// Roots returns the current roots of the feed's merkle tree
+func (f *Feed) Roots() []merkle.Node {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.stream.Roots()
+}
+
+// Close closes the feed, after which no blocks can be appended or read
+func (f *Feed) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	f.closed = true
+
+	if err := f.signatures.Close(); err != nil {
+		return err
+	}
+	if err := f.nodes.Close(); err != nil {
+		return err
+	}
+	if err := f.bitfields.Close(); err != nil {
+		return err
+	}
+	if err := f.forkStore.Close(); err != nil {
+		return err
+	}
+	return f.data.Close()
+}
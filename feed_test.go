@@ -0,0 +1,431 @@
+package hypercore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/sleep"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_Empty(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), feed.Len())
+	assert.Equal(t, uint64(0), feed.ByteLength())
+	assert.Empty(t, feed.Roots())
+
+	_, err = feed.Get(0)
+	assert.Equal(t, ErrOutOfBounds, err)
+}
+
+func Test_Feed_AppendAndGet(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	seq, err := feed.Append([]byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), seq)
+
+	seq, err = feed.Append([]byte("world"), []byte("foo"), []byte("bar"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), seq)
+
+	assert.Equal(t, uint64(4), feed.Len())
+	assert.Equal(t, uint64(16), feed.ByteLength())
+
+	for i, expected := range []string{"hello", "world", "foo", "bar"} {
+		data, err := feed.Get(uint64(i))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(expected), data)
+		assert.True(t, feed.Has(uint64(i)))
+	}
+
+	_, err = feed.Get(4)
+	assert.Equal(t, ErrOutOfBounds, err)
+	assert.False(t, feed.Has(4))
+	assert.Equal(t, uint64(4), feed.ContiguousLength())
+}
+
+func Test_Feed_Roots(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint(i)))
+		assert.NoError(t, err)
+	}
+
+	roots := feed.Roots()
+	assert.Len(t, roots, 2)
+	assert.Equal(t, uint64(3), roots[0].Index())
+	assert.Equal(t, uint64(8), roots[1].Index())
+}
+
+func Test_Feed_Close(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("hello"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, feed.Close())
+	assert.Equal(t, ErrClosed, feed.Close())
+
+	_, err = feed.Append([]byte("world"))
+	assert.Equal(t, ErrClosed, err)
+
+	_, err = feed.Get(0)
+	assert.Equal(t, ErrClosed, err)
+}
+
+func Test_Feed_FileStorage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+
+	_, err = feed.Append([]byte("hello"), []byte(""), []byte("world"))
+	assert.NoError(t, err)
+
+	data, err := feed.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("world"), data)
+
+	data, err = feed.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{}, data)
+	assert.NoError(t, feed.Close())
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("helloworld"), contents)
+}
+
+func Test_Feed_SignsRoots(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	assert.True(t, feed.Writable())
+
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"))
+	assert.NoError(t, err)
+
+	signature, err := feed.Signature(2)
+	assert.NoError(t, err)
+	assert.True(t, feed.VerifyRoots(feed.Roots(), signature))
+	// signatures are of the bare tree hash, as in hypercore v9 signatures files
+	assert.True(t, crypto.Verify(feed.PublicKey(), merkle.BLAKE2b256{}.HashRoots(feed.Roots()), signature))
+
+	_, err = feed.Append([]byte("d"))
+	assert.NoError(t, err)
+	assert.False(t, feed.VerifyRoots(feed.Roots(), signature))
+
+	signature, err = feed.Signature(3)
+	assert.NoError(t, err)
+	assert.True(t, feed.VerifyRoots(feed.Roots(), signature))
+
+	_, err = feed.Signature(4)
+	assert.Equal(t, ErrOutOfBounds, err)
+
+	// only the last length of an append batch is signed
+	_, err = feed.Signature(0)
+	assert.Equal(t, ErrUnsignedLength, err)
+	_, err = feed.Signature(1)
+	assert.Equal(t, ErrUnsignedLength, err)
+}
+
+func Test_Feed_KeyPair(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir), KeyPair: &keyPair})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(keyPair.PublicKey), feed.PublicKey())
+	assert.NoError(t, feed.Close())
+
+	// the keys are loaded from storage when not provided
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(keyPair.PublicKey), feed.PublicKey())
+	assert.True(t, feed.Writable())
+	assert.NoError(t, feed.Close())
+}
+
+func Test_Feed_KeyPairMismatch(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	other, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir), KeyPair: &keyPair})
+	assert.NoError(t, err)
+	assert.NoError(t, feed.Close())
+
+	_, err = NewFeed(Options{Storage: storage.DirProvider(dir), KeyPair: &other})
+	assert.Equal(t, ErrKeyMismatch, err)
+
+	// the stored keys are left untouched
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(keyPair.PublicKey), feed.PublicKey())
+	assert.NoError(t, feed.Close())
+
+	// providing the stored public key is still allowed
+	readOnly, err := crypto.NewKeyPair(keyPair.PublicKey, nil)
+	assert.NoError(t, err)
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir), KeyPair: &readOnly})
+	assert.NoError(t, err)
+	assert.NoError(t, feed.Close())
+}
+
+func Test_Feed_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	readOnly, err := crypto.NewKeyPair(keyPair.PublicKey, nil)
+	assert.NoError(t, err)
+
+	feed, err := NewFeed(Options{KeyPair: &readOnly})
+	assert.NoError(t, err)
+	assert.False(t, feed.Writable())
+
+	_, err = feed.Append([]byte("hello"))
+	assert.Equal(t, ErrNotWritable, err)
+	assert.Equal(t, uint64(0), feed.Len())
+}
+
+func Test_Feed_StoresTree(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+
+	_, err = feed.Append([]byte("a"), []byte("bc"), []byte("def"), []byte("ghij"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), feed.ByteLength())
+
+	root, err := feed.node(3)
+	assert.NoError(t, err)
+	assert.Equal(t, feed.Roots()[0].Hash(), root.Hash())
+	assert.Equal(t, uint64(10), root.Size())
+
+	offset, err := feed.dataOffset(3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), offset)
+	assert.NoError(t, feed.Close())
+
+	info, err := os.Stat(filepath.Join(dir, "tree"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(sleep.HeaderSize+7*sleep.TreeEntrySize), info.Size())
+}
+
+func Test_Feed_Seek(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	_, _, err = feed.Seek(0)
+	assert.Equal(t, ErrSeekBounds, err)
+
+	_, err = feed.Append([]byte("a"), []byte("bc"), []byte(""), []byte("def"), []byte("ghij"))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		byteOffset, index, relativeOffset uint64
+	}{
+		{0, 0, 0},
+		{1, 1, 0},
+		{2, 1, 1},
+		{3, 3, 0},
+		{5, 3, 2},
+		{6, 4, 0},
+		{9, 4, 3},
+	}
+
+	for _, tc := range testCases {
+		index, relativeOffset, err := feed.Seek(tc.byteOffset)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.index, index, "index of byte offset %d", tc.byteOffset)
+		assert.Equal(t, tc.relativeOffset, relativeOffset, "relative offset of byte offset %d", tc.byteOffset)
+	}
+
+	_, _, err = feed.Seek(10)
+	assert.Equal(t, ErrSeekBounds, err)
+}
+
+func Benchmark_FeedSeek(b *testing.B) {
+	feed, err := NewFeed(Options{})
+	assert.NoError(b, err)
+	for i := 0; i < 10000; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint(i)))
+		assert.NoError(b, err)
+	}
+
+	byteLength := feed.ByteLength()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_, _, err = feed.Seek(uint64(n) % byteLength)
+		assert.NoError(b, err)
+	}
+}
+
+func Test_Feed_Reopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("bc"), []byte("def"), []byte("ghij"), []byte("klmno"))
+	assert.NoError(t, err)
+	roots := feed.Roots()
+	assert.NoError(t, feed.Close())
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), feed.Len())
+	assert.Equal(t, uint64(15), feed.ByteLength())
+	assert.True(t, feed.Has(4))
+	assert.True(t, feed.tree.Get(3))
+
+	resumedRoots := feed.Roots()
+	assert.Len(t, resumedRoots, len(roots))
+	for i := range roots {
+		assert.Equal(t, roots[i].Index(), resumedRoots[i].Index())
+		assert.Equal(t, roots[i].Hash(), resumedRoots[i].Hash())
+		assert.Equal(t, roots[i].Size(), resumedRoots[i].Size())
+	}
+
+	data, err := feed.Get(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ghij"), data)
+
+	seq, err := feed.Append([]byte("pqrstu"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), seq)
+	assert.Equal(t, uint64(21), feed.ByteLength())
+
+	signature, err := feed.Signature(5)
+	assert.NoError(t, err)
+	assert.True(t, feed.VerifyRoots(feed.Roots(), signature))
+	assert.NoError(t, feed.Close())
+
+	// the resumed feed builds the same tree as one appended in a single session
+	expected, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = expected.Append([]byte("a"), []byte("bc"), []byte("def"), []byte("ghij"), []byte("klmno"), []byte("pqrstu"))
+	assert.NoError(t, err)
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	for i, root := range expected.Roots() {
+		assert.Equal(t, root.Hash(), feed.Roots()[i].Hash())
+	}
+}
+
+func Test_Feed_Writer(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte("hypercore"), 100)
+	writer, err := feed.Writer(128)
+	assert.NoError(t, err)
+	_, err = io.Copy(writer, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, uint64(8), feed.Len())
+	assert.Equal(t, uint64(len(data)), feed.ByteLength())
+
+	last, err := feed.Get(7)
+	assert.NoError(t, err)
+	assert.Equal(t, data[7*128:], last)
+
+	proof, err := feed.Proof(7, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(proof, last, feed.PublicKey()))
+}
+
+func Test_Feed_CDCWriter(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte("content defined chunking "), 2000)
+	writer, err := feed.CDCWriter(merkle.CDCOptions{MinSize: 256, AvgSize: 1024, MaxSize: 4096})
+	assert.NoError(t, err)
+	_, err = io.Copy(writer, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	assert.True(t, feed.Len() > 1)
+	assert.Equal(t, uint64(len(data)), feed.ByteLength())
+
+	stored := []byte{}
+	for i := uint64(0); i < feed.Len(); i++ {
+		block, err := feed.Get(i)
+		assert.NoError(t, err)
+		stored = append(stored, block...)
+	}
+	assert.Equal(t, data, stored)
+}
+
+// manyBlocks returns count one byte blocks
+func manyBlocks(count int) [][]byte {
+	blocks := make([][]byte, count)
+	for i := range blocks {
+		blocks[i] = []byte{byte(i)}
+	}
+	return blocks
+}
+
+func Test_Feed_ReopenAcrossBitfieldPages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+
+	// the second append completes the root, node 16383, which lies in the first page of the tree bitfield
+	blocksPerPage := sleep.DataPageSize * 8
+	_, err = feed.Append(manyBlocks(blocksPerPage)...)
+	assert.NoError(t, err)
+	_, err = feed.Append(manyBlocks(blocksPerPage)...)
+	assert.NoError(t, err)
+	assert.True(t, feed.tree.Get(16383))
+	assert.NoError(t, feed.Close())
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.True(t, feed.tree.Get(16383))
+	assert.Equal(t, uint64(2*blocksPerPage), feed.ContiguousLength())
+	assert.NoError(t, feed.Close())
+}
@@ -0,0 +1,117 @@
+package indexed
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+	ft "github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+var (
+	ErrInvalidEncoding = errors.New("encoded tree has an unknown encoding")
+	ErrCorruptTree     = errors.New("tree has a set parent without both of its children set")
+)
+
+// flags describing how the bitfield of an encoded tree is stored
+const (
+	rawEncoding byte = iota
+	rleEncoding
+)
+
+// Encode serializes the bitfield of the tree, run length encoding it when that is smaller
+//
+//	1 byte encoding (0 raw, 1 run length encoded) | bitfield bytes
+func (t *Tree) Encode() []byte {
+	data := make([]byte, t.bitfield.ByteLength())
+	for i := range data {
+		data[i] = t.bitfield.GetByte(uint64(i))
+	}
+
+	encoded, compressed := bitfield.Encode(data)
+	if compressed {
+		return append([]byte{rleEncoding}, encoded...)
+	}
+	return append([]byte{rawEncoding}, data...)
+}
+
+// DecodeTree restores a tree serialized by Encode, checking its integrity
+func DecodeTree(buf []byte) (*Tree, error) {
+	if len(buf) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	data := buf[1:]
+	switch buf[0] {
+	case rawEncoding:
+	case rleEncoding:
+		decoded, err := bitfield.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	default:
+		return nil, ErrInvalidEncoding
+	}
+
+	bf := bitfield.NewBitfield(0)
+	for i, b := range data {
+		// the final byte is always set, preserving the length of the bitfield
+		if b != 0 || i == len(data)-1 {
+			bf.SetByte(uint64(i), b)
+		}
+	}
+
+	tree := NewTree(bf)
+	if err := tree.Validate(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Validate checks the integrity of the tree: every set parent must have both of its children set
+func (t *Tree) Validate() error {
+	for i := uint64(0); i < t.bitfield.ByteLength(); i++ {
+		// parents have odd indices
+		if t.bitfield.GetByte(i)&^leafBits == 0 {
+			continue
+		}
+
+		for index := i*8 + 1; index < (i+1)*8; index += 2 {
+			if !t.Get(index) {
+				continue
+			}
+			left, right, _ := ft.Children(index)
+			if !t.Get(left) || !t.Get(right) {
+				return fmt.Errorf("%w: node %d", ErrCorruptTree, index)
+			}
+		}
+	}
+	return nil
+}
+
+// Save writes the encoded tree to the storage, replacing its contents
+func (t *Tree) Save(s storage.Storage) error {
+	if err := s.Truncate(0); err != nil {
+		return err
+	}
+	return s.Write(0, t.Encode())
+}
+
+// LoadTree restores a tree saved to the storage, or an empty tree if the storage is empty
+func LoadTree(s storage.Storage) (*Tree, error) {
+	stat, err := s.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size == 0 {
+		return NewDefaultTree(), nil
+	}
+
+	buf, err := s.Read(0, stat.Size)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTree(buf)
+}
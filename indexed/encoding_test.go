@@ -0,0 +1,104 @@
+package indexed
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Tree_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	for block := uint64(0); block < 1000; block++ {
+		tree.Set(block * 2)
+	}
+	tree.Set(5000)
+
+	encoded := tree.Encode()
+	assert.Equal(t, rleEncoding, encoded[0])
+	assert.True(t, len(encoded) < int(tree.Bitfield().ByteLength()))
+
+	decoded, err := DecodeTree(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, tree.Bitfield().ByteLength(), decoded.Bitfield().ByteLength())
+	for index := uint64(0); index < tree.Bitfield().Len(); index++ {
+		assert.Equal(t, tree.Get(index), decoded.Get(index), "index %d", index)
+	}
+	assert.Equal(t, uint64(1000), decoded.ContiguousLength())
+	assert.Equal(t, uint64(2501), decoded.Blocks())
+}
+
+func Test_Tree_EncodeRaw(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	tree.Set(0)
+	tree.Set(6)
+
+	encoded := tree.Encode()
+	assert.Equal(t, rawEncoding, encoded[0])
+
+	decoded, err := DecodeTree(encoded)
+	assert.NoError(t, err)
+	assert.True(t, decoded.Get(0))
+	assert.True(t, decoded.Get(6))
+	assert.False(t, decoded.Get(2))
+
+	empty, err := DecodeTree(NewDefaultTree().Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), empty.Blocks())
+}
+
+func Test_DecodeTree_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeTree(nil)
+	assert.Equal(t, ErrInvalidEncoding, err)
+
+	_, err = DecodeTree([]byte{2, 0})
+	assert.Equal(t, ErrInvalidEncoding, err)
+
+	// node 1 is set without its right child, node 2
+	_, err = DecodeTree([]byte{rawEncoding, 0b011})
+	assert.True(t, errors.Is(err, ErrCorruptTree))
+}
+
+func Test_Tree_Validate(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	for _, index := range []uint64{0, 2, 4, 6, 10} {
+		tree.Set(index)
+	}
+	assert.NoError(t, tree.Validate())
+
+	tree.Bitfield().SetBit(4, false)
+	assert.True(t, errors.Is(tree.Validate(), ErrCorruptTree))
+}
+
+func Test_Tree_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	mem := storage.NewMemory(0)
+	tree, err := LoadTree(mem)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), tree.Blocks())
+
+	for block := uint64(0); block < 20; block++ {
+		tree.Set(block * 2)
+	}
+	assert.NoError(t, tree.Save(mem))
+
+	// saving a smaller tree replaces the larger one
+	tree.Truncate(10)
+	assert.NoError(t, tree.Save(mem))
+
+	loaded, err := LoadTree(mem)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), loaded.Blocks())
+	assert.Equal(t, uint64(5), loaded.ContiguousLength())
+	assert.True(t, loaded.Get(3))
+}
@@ -0,0 +1,109 @@
+package indexed
+
+import (
+	"sync"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+)
+
+// PeerView models the blocks and nodes a remote peer holds, as learned from its announcements
+// and from the proofs sent to it, so that later proofs only carry the nodes it is missing
+// The nodes sent in proofs are kept apart from the blocks, as the peer holds the hashes of the uncles
+// of a proven block without holding the blocks beneath them
+type PeerView struct {
+	nodes  *Tree // nodes whose hashes the peer holds, as blocks it holds or as nodes it has been sent
+	blocks *Tree // blocks the peer holds, along with the parents of the subtrees it holds in full
+	mu     *sync.Mutex
+}
+
+// NewPeerView constructs the view of a peer known to hold nothing
+func NewPeerView() *PeerView {
+	return &PeerView{
+		nodes:  NewDefaultTree(),
+		blocks: NewDefaultTree(),
+		mu:     &sync.Mutex{},
+	}
+}
+
+// Have records the announcement that the peer holds the blocks in the range [start, start+length)
+func (p *PeerView) Have(start, length uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for block := start; block < start+length; block++ {
+		p.setBlock(block)
+	}
+}
+
+// HaveBitfield records the announcement of every block the peer holds, one bit per block
+func (p *PeerView) HaveBitfield(announced *bitfield.Bitfield) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := uint64(0); i < announced.ByteLength(); i++ {
+		b := announced.GetByte(i)
+		for bit := uint64(0); b != 0; bit++ {
+			if b&1 != 0 {
+				p.setBlock(i*8 + bit)
+			}
+			b >>= 1
+		}
+	}
+}
+
+// setBlock marks the block, and its leaf, as held by the peer
+// Must be called while holding the lock
+func (p *PeerView) setBlock(block uint64) {
+	p.blocks.Set(block * 2)
+	p.nodes.Set(block * 2)
+}
+
+// Has checks if the peer holds the block
+func (p *PeerView) Has(block uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.blocks.Get(block * 2)
+}
+
+// Digest returns the digest of the nodes the peer holds around the node at index, as computed by Tree Digest
+func (p *PeerView) Digest(index uint64) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.nodes.Digest(index)
+}
+
+// Proof builds the proof of the node at index from the local tree, omitting every node the peer holds,
+// and records the proven node and the nodes of the proof as held by the peer
+func (p *PeerView) Proof(local *Tree, index uint64) (proof Proof, verified bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proof, verified, err = local.Proof(index, p.nodes.Digest(index), p.nodes)
+	if err != nil || !verified {
+		return
+	}
+
+	for _, node := range proof.Nodes() {
+		p.nodes.Set(node)
+	}
+	if isEven(index) {
+		p.blocks.Set(index)
+	}
+
+	return
+}
+
+// Tree returns a copy of the tree of blocks the peer holds, for block queries such as NextMissing
+// Nodes the peer has only been sent as part of proofs are not included
+func (p *PeerView) Tree() *Tree {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	copied := bitfield.NewBitfield(0)
+	for i := uint64(0); i < p.blocks.bitfield.ByteLength(); i++ {
+		copied.SetByte(i, p.blocks.bitfield.GetByte(i))
+	}
+	return NewTree(copied)
+}
@@ -0,0 +1,124 @@
+package indexed
+
+import (
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PeerView_Have(t *testing.T) {
+	t.Parallel()
+
+	view := NewPeerView()
+	assert.False(t, view.Has(0))
+
+	view.Have(0, 2)
+	assert.True(t, view.Has(0))
+	assert.True(t, view.Has(1))
+	assert.False(t, view.Has(2))
+	assert.True(t, view.Tree().Get(1))
+	assert.Equal(t, uint64(1), view.Digest(0))
+
+	announced := bitfield.NewBitfield(0)
+	announced.SetBit(2, true)
+	announced.SetBit(3, true)
+	announced.SetBit(9, true)
+	view.HaveBitfield(announced)
+
+	tree := view.Tree()
+	assert.True(t, tree.Get(3))
+	assert.True(t, tree.Get(18))
+	assert.True(t, view.Has(9))
+	assert.False(t, view.Has(8))
+	assert.Equal(t, uint64(4), tree.ContiguousLength())
+}
+
+func Test_PeerView_Proof(t *testing.T) {
+	t.Parallel()
+
+	local := NewDefaultTree()
+	for block := uint64(0); block < 8; block++ {
+		local.Set(block * 2)
+	}
+
+	view := NewPeerView()
+	proof, verified, err := view.Proof(local, 0)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, []uint64{0, 2, 5, 11}, proof.Nodes())
+	assert.Equal(t, uint64(16), proof.VerifiedBy())
+	assert.True(t, view.Has(0))
+
+	// the peer already holds the hash of block 1, having been sent it as part of the first proof
+	proof, verified, err = view.Proof(local, 2)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, []uint64{2}, proof.Nodes())
+
+	// only the sibling of block 2 is needed, its parent having been sent before
+	proof, verified, err = view.Proof(local, 4)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, []uint64{4, 6}, proof.Nodes())
+	assert.Equal(t, uint64(0), proof.VerifiedBy())
+
+	// a fresh remote tree requires the full proof
+	proof, _, err = local.Proof(4, 0, NewDefaultTree())
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 6, 1, 11}, proof.Nodes())
+
+	_, verified, err = view.Proof(local, 16)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+	assert.False(t, view.Has(8))
+}
+
+func Test_PeerView_ProofKeepsBlockTree(t *testing.T) {
+	t.Parallel()
+
+	local := NewDefaultTree()
+	for block := uint64(0); block < 8; block++ {
+		local.Set(block * 2)
+	}
+
+	// the uncles sent with block 0 do not mark the blocks beneath them as held
+	view := NewPeerView()
+	_, _, err := view.Proof(local, 0)
+	assert.NoError(t, err)
+	assert.False(t, view.Has(1))
+
+	tree := view.Tree()
+	assert.NoError(t, tree.Validate())
+	assert.False(t, tree.Get(2))
+	assert.False(t, tree.Get(5))
+	assert.Equal(t, uint64(1), tree.NextMissing(0))
+	assert.Equal(t, []Range{{Start: 1, End: 8}}, tree.MissingRanges(0, 8))
+
+	_, _, err = view.Proof(local, 2)
+	assert.NoError(t, err)
+	assert.True(t, view.Has(1))
+
+	tree = view.Tree()
+	assert.NoError(t, tree.Validate())
+	assert.True(t, tree.Get(1))
+	assert.Equal(t, uint64(2), tree.ContiguousLength())
+	assert.Equal(t, []Range{{Start: 2, End: 8}}, tree.MissingRanges(0, 8))
+}
+
+func Test_PeerView_ProofAfterHave(t *testing.T) {
+	t.Parallel()
+
+	local := NewDefaultTree()
+	for block := uint64(0); block < 4; block++ {
+		local.Set(block * 2)
+	}
+
+	view := NewPeerView()
+	view.Have(0, 2)
+
+	proof, verified, err := view.Proof(local, 4)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, []uint64{4, 6}, proof.Nodes())
+}
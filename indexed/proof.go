@@ -1,7 +1,24 @@
 package indexed
 
+// Proof lists the nodes a tree sends to prove a node to a remote tree
 type Proof struct {
 	index      uint64
 	verifiedBy uint64
 	nodes      []uint64
 }
+
+// Index returns the flat tree index of the node being proven
+func (p Proof) Index() uint64 {
+	return p.index
+}
+
+// VerifiedBy returns the index bounding the roots which verify the proof, as passed to flattree.FullRoots
+// A value of 0 means the proof is verified by nodes the remote tree already holds
+func (p Proof) VerifiedBy() uint64 {
+	return p.verifiedBy
+}
+
+// Nodes returns the flat tree indices of the nodes making up the proof, starting with the proven node
+func (p Proof) Nodes() []uint64 {
+	return p.nodes
+}
@@ -1,36 +1,58 @@
 package indexed
 
 import (
+	"math/bits"
+
 	"github.com/kiambogo/go-hypercore/bitfield"
 	ft "github.com/kiambogo/go-hypercore/flattree"
 )
 
+// Verification describes the nodes verifying a node held by a tree
 type Verification struct {
 	node uint64
 	top  uint64
 }
 
-type tree struct {
+// Node returns the index bounding the roots which verify the node, as passed to flattree.FullRoots
+func (v Verification) Node() uint64 {
+	return v.node
+}
+
+// Top returns the highest held ancestor of the node
+func (v Verification) Top() uint64 {
+	return v.top
+}
+
+// Tree indexes which nodes of a flat tree are held, backed by a bitfield with one bit per node
+// A parent is set whenever both of its children are
+type Tree struct {
 	bitfield *bitfield.Bitfield
 }
 
-func NewTree(bitfield *bitfield.Bitfield) tree {
-	return tree{
+// NewTree constructs a tree index backed by the provided bitfield
+func NewTree(bitfield *bitfield.Bitfield) *Tree {
+	return &Tree{
 		bitfield: bitfield,
 	}
 }
 
-func NewDefaultTree() tree {
-	return tree{
+// NewDefaultTree constructs an empty tree index
+func NewDefaultTree() *Tree {
+	return &Tree{
 		bitfield: bitfield.NewBitfield(0),
 	}
 }
 
-func (t tree) Get(index uint64) bool {
+// Bitfield returns the bitfield backing the tree
+func (t *Tree) Bitfield() *bitfield.Bitfield {
+	return t.bitfield
+}
+
+func (t *Tree) Get(index uint64) bool {
 	return t.bitfield.GetBit(index)
 }
 
-func (t *tree) Set(index uint64) bool {
+func (t *Tree) Set(index uint64) bool {
 	// update the element in the tree at index
 	if !t.bitfield.SetBit(int(index), true) {
 		return false
@@ -47,7 +69,36 @@ func (t *tree) Set(index uint64) bool {
 	return true
 }
 
-func (t tree) Proof(index, digest uint64, remoteTree tree) (proof Proof, verified bool, err error) {
+// Unset clears the node at index in the tree, along with all of its ancestors
+// Returns true if the node was previously set
+func (t *Tree) Unset(index uint64) bool {
+	if !t.bitfield.SetBit(int(index), false) {
+		return false
+	}
+
+	t.unsetAncestors(index)
+	return true
+}
+
+// Truncate clears every node in the tree which spans the leaf at index, or any leaf after it
+func (t *Tree) Truncate(index uint64) {
+	length := t.bitfield.Len()
+	for i := index; i < length; i++ {
+		t.bitfield.SetBit(int(i), false)
+	}
+
+	t.unsetAncestors(index)
+}
+
+// unsetAncestors clears every ancestor of index, up to the depth beyond the end of the bitfield
+func (t *Tree) unsetAncestors(index uint64) {
+	length := t.bitfield.Len()
+	for parent := ft.Parent(index); ft.Index(ft.Depth(parent), 0) < length; parent = ft.Parent(parent) {
+		t.bitfield.SetBit(int(parent), false)
+	}
+}
+
+func (t *Tree) Proof(index, digest uint64, remoteTree *Tree) (proof Proof, verified bool, err error) {
 	var roots []uint64
 
 	if !t.Get(index) {
@@ -134,7 +185,7 @@ func (t tree) Proof(index, digest uint64, remoteTree tree) (proof Proof, verifie
 
 // Digest will calculate the digest of the data at a particular index
 // It does this by checking the uncles in the merkle tree
-func (t tree) Digest(index uint64) (digest uint64) {
+func (t *Tree) Digest(index uint64) (digest uint64) {
 	if t.Get(index) {
 		return 1
 	}
@@ -165,7 +216,7 @@ func (t tree) Digest(index uint64) (digest uint64) {
 	return
 }
 
-func (t tree) VerifiedBy(index uint64) (verification Verification) {
+func (t *Tree) VerifiedBy(index uint64) (verification Verification) {
 	if !t.Get(index) {
 		return
 	}
@@ -196,6 +247,95 @@ func (t tree) VerifiedBy(index uint64) (verification Verification) {
 	return Verification{node: top, top: top}
 }
 
+// leafBits masks the bits of leaf nodes within a byte of the bitfield, as leaves have even indices
+const leafBits = 0x55
+
+// HighestBlock returns the highest block whose leaf is set, and false if no leaf is set
+func (t *Tree) HighestBlock() (block uint64, ok bool) {
+	for i := t.bitfield.ByteLength(); i > 0; i-- {
+		leaves := t.bitfield.GetByte(i-1) & leafBits
+		if leaves == 0 {
+			continue
+		}
+		leaf := (i-1)*8 + uint64(7-bits.LeadingZeros8(leaves))
+		return leaf / 2, true
+	}
+	return 0, false
+}
+
+// Blocks returns the number of blocks spanned by the tree, being one past the highest block set
+func (t *Tree) Blocks() uint64 {
+	block, ok := t.HighestBlock()
+	if !ok {
+		return 0
+	}
+	return block + 1
+}
+
+// ContiguousLength returns the number of blocks set contiguously from block 0
+func (t *Tree) ContiguousLength() uint64 {
+	return t.NextMissing(0)
+}
+
+// NextMissing returns the first block at or after the provided block whose leaf is not set
+// Runs of set blocks are skipped a full subtree at a time, using the parent bits
+func (t *Tree) NextMissing(block uint64) uint64 {
+	for t.Get(block * 2) {
+		// climb to the largest set subtree starting at the block
+		depth := uint64(0)
+		for block%(1<<(depth+1)) == 0 && t.Get(ft.Index(depth+1, block>>(depth+1))) {
+			depth++
+		}
+		block += 1 << depth
+	}
+	return block
+}
+
+// Range is a run of blocks in the range [Start, End)
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// MissingRanges returns the runs of blocks within [start, end) whose leaves are not set, in order
+func (t *Tree) MissingRanges(start, end uint64) []Range {
+	ranges := []Range{}
+	for block := t.NextMissing(start); block < end; {
+		next := t.nextSet(block, end)
+		ranges = append(ranges, Range{Start: block, End: next})
+		if next == end {
+			break
+		}
+		block = t.NextMissing(next)
+	}
+	return ranges
+}
+
+// nextSet returns the first block at or after the provided block whose leaf is set, or end if there is none before it
+// Blocks are skipped a byte of the bitfield at a time while no leaf within the byte is set
+func (t *Tree) nextSet(block, end uint64) uint64 {
+	for block < end {
+		if block*2 >= t.bitfield.Len() {
+			return end
+		}
+		if block%4 == 0 && t.bitfield.GetByte(block/4)&leafBits == 0 {
+			block += 4
+			continue
+		}
+		if t.Get(block * 2) {
+			return block
+		}
+		block++
+	}
+	return end
+}
+
+// FullRoots returns the indices of the roots of the tree spanning every block up to the highest block set
+// Roots of subtrees with missing blocks are included, so the roots themselves may not be set
+func (t *Tree) FullRoots() ([]uint64, error) {
+	return ft.FullRoots(t.Blocks() * 2)
+}
+
 func max(x, y uint64) uint64 {
 	if x >= y {
 		return x
@@ -57,23 +57,66 @@ func Test_GetAndSet(t *testing.T) {
 	})
 }
 
+func Test_Unset(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	for _, index := range []uint64{0, 2, 4, 6} {
+		tree.Set(index)
+	}
+	assert.True(t, tree.Get(3))
+
+	assert.True(t, tree.Unset(4))
+	assert.False(t, tree.Unset(4))
+	assert.False(t, tree.Get(4))
+	assert.False(t, tree.Get(5))
+	assert.False(t, tree.Get(3))
+	assert.True(t, tree.Get(1))
+	assert.True(t, tree.Get(6))
+
+	tree.Set(4)
+	assert.True(t, tree.Get(3))
+}
+
+func Test_Truncate(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	for i := uint64(0); i < 16; i += 2 {
+		tree.Set(i)
+	}
+	assert.True(t, tree.Get(7))
+
+	tree.Truncate(6)
+	for _, index := range []uint64{0, 1, 2, 4} {
+		assert.True(t, tree.Get(index), "index %d should remain set", index)
+	}
+	for _, index := range []uint64{3, 5, 6, 7, 8, 11, 14} {
+		assert.False(t, tree.Get(index), "index %d should be cleared", index)
+	}
+
+	tree.Truncate(0)
+	assert.False(t, tree.Get(0))
+	assert.False(t, tree.Get(1))
+}
+
 func Test_Digest(t *testing.T) {
 	testCases := []struct {
 		name           string
 		index          uint64
-		ops            func(tree tree)
+		ops            func(tree *Tree)
 		expectedDigest uint64
 	}{
 		{
 			name:           "empty tree",
 			index:          0,
-			ops:            func(tree tree) {},
+			ops:            func(tree *Tree) {},
 			expectedDigest: 0b0,
 		},
 		{
 			name:  "full tree",
 			index: 0,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(0)
 			},
 			expectedDigest: 0b1,
@@ -81,7 +124,7 @@ func Test_Digest(t *testing.T) {
 		{
 			name:  "rooted, no sibling, no parent",
 			index: 0,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(1)
 			},
 			expectedDigest: 0b101,
@@ -89,7 +132,7 @@ func Test_Digest(t *testing.T) {
 		{
 			name:  "not rooted, has sibling",
 			index: 0,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(2)
 			},
 			expectedDigest: 0b10,
@@ -97,7 +140,7 @@ func Test_Digest(t *testing.T) {
 		{
 			name:  "full tree, 2",
 			index: 0,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(1)
 				tree.Set(2)
 			},
@@ -106,7 +149,7 @@ func Test_Digest(t *testing.T) {
 		{
 			name:  "rooted, sibling, no uncle, grand parents",
 			index: 0,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(3)
 				tree.Set(2)
 			},
@@ -115,7 +158,7 @@ func Test_Digest(t *testing.T) {
 		{
 			name:  "not rooted, has sibling",
 			index: 1,
-			ops: func(tree tree) {
+			ops: func(tree *Tree) {
 				tree.Set(5)
 			},
 			expectedDigest: 0b10,
@@ -357,3 +400,172 @@ func Test_ProofWithDigest3(t *testing.T) {
 	assert.Equal(t, Proof{index: 17, verifiedBy: 0, nodes: []uint64{17, 21}}, proof)
 	assert.True(t, verified)
 }
+
+func Test_ProofAccessors(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	tree.Set(0)
+	tree.Set(2)
+	tree.Set(5)
+
+	proof, verified, err := tree.Proof(0, 0, NewDefaultTree())
+	assert.NoError(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, uint64(0), proof.Index())
+	assert.Equal(t, uint64(8), proof.VerifiedBy())
+	assert.Equal(t, []uint64{0, 2, 5}, proof.Nodes())
+}
+
+func Test_Verification_Accessors(t *testing.T) {
+	t.Parallel()
+
+	bf := bitfield.NewBitfield(0)
+	tree := NewTree(bf)
+	assert.Equal(t, bf, tree.Bitfield())
+
+	for _, index := range []uint64{0, 2, 4} {
+		tree.Set(index)
+	}
+
+	verification := tree.VerifiedBy(0)
+	assert.Equal(t, uint64(6), verification.Node())
+	assert.Equal(t, uint64(4), verification.Top())
+}
+
+func Test_Tree_HighestBlock(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	_, ok := tree.HighestBlock()
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), tree.Blocks())
+
+	tree.Set(0)
+	block, ok := tree.HighestBlock()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), block)
+
+	// parents set above the highest leaf are not blocks
+	tree.Set(2)
+	tree.Set(20)
+	block, ok = tree.HighestBlock()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), block)
+	assert.Equal(t, uint64(11), tree.Blocks())
+
+	tree.Unset(20)
+	assert.Equal(t, uint64(2), tree.Blocks())
+}
+
+func Test_Tree_ContiguousLength(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	assert.Equal(t, uint64(0), tree.ContiguousLength())
+
+	tree.Set(2)
+	assert.Equal(t, uint64(0), tree.ContiguousLength())
+
+	tree.Set(0)
+	assert.Equal(t, uint64(2), tree.ContiguousLength())
+
+	for block := uint64(2); block < 13; block++ {
+		tree.Set(block * 2)
+	}
+	tree.Set(28)
+	assert.Equal(t, uint64(13), tree.ContiguousLength())
+
+	tree.Set(26)
+	assert.Equal(t, uint64(15), tree.ContiguousLength())
+
+	tree.Unset(8)
+	assert.Equal(t, uint64(4), tree.ContiguousLength())
+}
+
+func Test_Tree_FullRoots(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	roots, err := tree.FullRoots()
+	assert.NoError(t, err)
+	assert.Empty(t, roots)
+
+	for block := uint64(0); block < 5; block++ {
+		tree.Set(block * 2)
+	}
+	roots, err = tree.FullRoots()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 8}, roots)
+
+	// a sparse tree still reports the roots spanning up to its highest block
+	tree.Set(12)
+	roots, err = tree.FullRoots()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 9, 12}, roots)
+	assert.False(t, tree.Get(9))
+}
+
+func Test_Tree_NextMissing(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	assert.Equal(t, uint64(0), tree.NextMissing(0))
+	assert.Equal(t, uint64(7), tree.NextMissing(7))
+
+	for block := uint64(0); block < 20; block++ {
+		if block != 9 {
+			tree.Set(block * 2)
+		}
+	}
+
+	assert.Equal(t, uint64(9), tree.NextMissing(0))
+	assert.Equal(t, uint64(9), tree.NextMissing(3))
+	assert.Equal(t, uint64(9), tree.NextMissing(9))
+	assert.Equal(t, uint64(20), tree.NextMissing(10))
+	assert.Equal(t, uint64(25), tree.NextMissing(25))
+}
+
+func Test_Tree_MissingRanges(t *testing.T) {
+	t.Parallel()
+
+	tree := NewDefaultTree()
+	assert.Equal(t, []Range{{Start: 0, End: 10}}, tree.MissingRanges(0, 10))
+
+	for _, block := range []uint64{0, 1, 2, 5, 6, 7, 8, 20} {
+		tree.Set(block * 2)
+	}
+
+	assert.Equal(t, []Range{{3, 5}, {9, 20}, {21, 30}}, tree.MissingRanges(0, 30))
+	assert.Equal(t, []Range{{4, 5}, {9, 12}}, tree.MissingRanges(4, 12))
+	assert.Equal(t, []Range{{9, 20}}, tree.MissingRanges(6, 21))
+	assert.Empty(t, tree.MissingRanges(5, 9))
+	assert.Empty(t, tree.MissingRanges(3, 3))
+
+	// the ranges match checking each block in turn
+	for start := uint64(0); start < 24; start++ {
+		for end := start; end < 24; end++ {
+			missing := map[uint64]bool{}
+			for _, r := range tree.MissingRanges(start, end) {
+				for block := r.Start; block < r.End; block++ {
+					missing[block] = true
+				}
+			}
+			for block := start; block < end; block++ {
+				assert.Equal(t, !tree.Get(block*2), missing[block], "block %d of [%d, %d)", block, start, end)
+			}
+		}
+	}
+}
+
+func Benchmark_Tree_NextMissing(b *testing.B) {
+	tree := NewDefaultTree()
+	for block := uint64(0); block < 1<<16; block++ {
+		tree.Set(block * 2)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		tree.NextMissing(uint64(n) % 1024)
+	}
+}
@@ -0,0 +1,118 @@
+package merkle
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var ErrInvalidChunkSizes = errors.New("chunk sizes must satisfy 0 < min <= avg <= max")
+
+// CDCOptions configures content-defined chunking
+// Unset sizes default to an average of 8KiB, a minimum of a quarter of the average, and a maximum of 8 times the average
+type CDCOptions struct {
+	MinSize int // no block is smaller than MinSize, except the final block
+	AvgSize int // the size blocks are normalized towards
+	MaxSize int // blocks are cut at MaxSize if no boundary is found before it
+}
+
+// gear is the table of random values mixed into the rolling hash for each byte
+// It is generated from a fixed seed, as boundaries must not change between versions
+var gear = func() (table [256]uint64) {
+	// splitmix64
+	state := uint64(0x6879706572636f72)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return
+}()
+
+// NewCDCWriter constructs a writer appending content-defined blocks to the appender
+// Block boundaries are found with the FastCDC gear hash, so an edit to the input only changes
+// the blocks around it, rather than every later block as with fixed size blocks
+func NewCDCWriter(appender BlockAppender, opts CDCOptions) (*Writer, error) {
+	split, err := cdcSplit(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{appender: appender, split: split}, nil
+}
+
+// cdcSplit returns the split function finding FastCDC boundaries
+// Below the average size a boundary requires more zero bits of the hash than above it,
+// normalizing the sizes of the blocks towards the average
+func cdcSplit(opts CDCOptions) (splitFunc, error) {
+	if opts.AvgSize == 0 {
+		opts.AvgSize = 8 * 1024
+	}
+	if opts.MinSize == 0 {
+		opts.MinSize = opts.AvgSize / 4
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = opts.AvgSize * 8
+	}
+	if opts.MinSize <= 0 || opts.MinSize > opts.AvgSize || opts.AvgSize > opts.MaxSize {
+		return nil, ErrInvalidChunkSizes
+	}
+
+	// the masks select the high bits of the hash, which depend on the most recent 64 bytes
+	avgBits := bits.Len(uint(opts.AvgSize)) - 1
+	strictMask := highBits(avgBits + 1)
+	looseMask := highBits(avgBits - 1)
+
+	return func(buf []byte, scanned int) int {
+		if len(buf) < opts.MinSize {
+			return 0
+		}
+
+		end := len(buf)
+		if end > opts.MaxSize {
+			end = opts.MaxSize
+		}
+		normal := opts.AvgSize
+		if normal > end {
+			normal = end
+		}
+
+		// the hash only depends on the most recent 64 bytes, so resuming the search needs no more
+		// than those bytes rehashed, without checking the boundaries already ruled out
+		var hash uint64
+		i := opts.MinSize
+		if scanned-64 > i {
+			i = scanned - 64
+		}
+		for ; i < scanned; i++ {
+			hash = (hash << 1) + gear[buf[i]]
+		}
+
+		for ; i < normal; i++ {
+			hash = (hash << 1) + gear[buf[i]]
+			if hash&strictMask == 0 {
+				return i + 1
+			}
+		}
+		for ; i < end; i++ {
+			hash = (hash << 1) + gear[buf[i]]
+			if hash&looseMask == 0 {
+				return i + 1
+			}
+		}
+
+		if end == opts.MaxSize {
+			return opts.MaxSize
+		}
+		// wait for more input, as a boundary may yet be found before the maximum size
+		return 0
+	}, nil
+}
+
+// highBits returns a mask of the n highest bits of a uint64
+func highBits(n int) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	return ^uint64(0) << (64 - uint(n))
+}
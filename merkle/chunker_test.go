@@ -0,0 +1,142 @@
+package merkle
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chunk splits the data with a CDC writer, writing it in pieces of the provided size
+func chunk(t *testing.T, data []byte, opts CDCOptions, writeSize int) [][]byte {
+	blocks := [][]byte{}
+	writer, err := NewCDCWriter(AppendFunc(func(batch [][]byte) error {
+		blocks = append(blocks, batch...)
+		return nil
+	}), opts)
+	assert.NoError(t, err)
+
+	for offset := 0; offset < len(data); offset += writeSize {
+		end := offset + writeSize
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err = writer.Write(data[offset:end])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+
+	return blocks
+}
+
+func Test_CDCWriter_Sizes(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	opts := CDCOptions{MinSize: 1024, AvgSize: 4096, MaxSize: 16384}
+
+	blocks := chunk(t, data, opts, 32*1024)
+	assert.Equal(t, data, bytes.Join(blocks, nil))
+
+	for i, block := range blocks {
+		assert.LessOrEqual(t, len(block), opts.MaxSize)
+		if i < len(blocks)-1 {
+			assert.GreaterOrEqual(t, len(block), opts.MinSize)
+		}
+	}
+
+	average := len(data) / len(blocks)
+	assert.True(t, average > opts.AvgSize/2 && average < opts.AvgSize*2, "average block size %d", average)
+
+	// the boundaries depend only on the content, not on how it is written
+	assert.Equal(t, blocks, chunk(t, data, opts, 1000))
+	assert.Equal(t, blocks, chunk(t, data, opts, 7))
+}
+
+func Test_CDCWriter_Dedup(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+	edited := append(append(append([]byte{}, data[:100000]...), 'x'), data[100000:]...)
+
+	opts := CDCOptions{AvgSize: 4096}
+	original := chunk(t, data, opts, 4096)
+	changed := chunk(t, edited, opts, 4096)
+
+	seen := map[string]bool{}
+	for _, block := range original {
+		seen[string(block)] = true
+	}
+	shared := 0
+	for _, block := range changed {
+		if seen[string(block)] {
+			shared++
+		}
+	}
+
+	// only the blocks around the inserted byte differ
+	assert.GreaterOrEqual(t, shared, len(changed)-3)
+}
+
+func Test_CDCWriter_Stream(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(3)).Read(data)
+	opts := CDCOptions{MinSize: 256, AvgSize: 1024, MaxSize: 4096}
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	writer, err := NewCDCWriter(stream, opts)
+	assert.NoError(t, err)
+	_, err = writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	expected := NewStream(BLAKE2b256{}, nil, nil)
+	blocks := chunk(t, data, opts, len(data))
+	assert.NoError(t, expected.AppendBatch(blocks))
+	assert.Equal(t, uint64(len(blocks)), stream.Blocks())
+	assert.Equal(t, expected.TreeHash(), stream.TreeHash())
+}
+
+func Test_CDCWriter_FlushRestartsSearch(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(4)).Read(data)
+	opts := CDCOptions{MinSize: 256, AvgSize: 1024, MaxSize: 4096}
+
+	blocks := [][]byte{}
+	writer, err := NewCDCWriter(AppendFunc(func(batch [][]byte) error {
+		blocks = append(blocks, batch...)
+		return nil
+	}), opts)
+	assert.NoError(t, err)
+
+	_, err = writer.Write(data[:100])
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Flush())
+	assert.Equal(t, [][]byte{data[:100]}, blocks)
+
+	// the input after a flush is chunked as if written to a new writer
+	_, err = writer.Write(data[100:])
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, chunk(t, data[100:], opts, len(data)), blocks[1:])
+}
+
+func Test_NewCDCWriter_InvalidSizes(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range []CDCOptions{
+		{MinSize: 4096, AvgSize: 1024},
+		{AvgSize: 1024, MaxSize: 512},
+		{MinSize: -1, AvgSize: 1024},
+	} {
+		_, err := NewCDCWriter(NewStream(BLAKE2b256{}, nil, nil), opts)
+		assert.Equal(t, ErrInvalidChunkSizes, err, "%+v", opts)
+	}
+}
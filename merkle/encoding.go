@@ -0,0 +1,81 @@
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// HashSize is the size of a node hash within the binary encoding of a node, in bytes
+	HashSize = 32
+	// EncodedNodeSize is the size of the binary encoding of a node: its hash followed by its size as a big endian uint64
+	EncodedNodeSize = HashSize + 8
+)
+
+var (
+	ErrInvalidHashSize = errors.New("node hash must be 32 bytes to be encoded")
+	ErrInvalidEncoding = errors.New("encoded nodes must be a multiple of 40 bytes")
+	ErrNodesNotInRun   = errors.New("nodes must have consecutive flat tree indices to be encoded as a run")
+)
+
+// MarshalBinary encodes the node in the 40 byte layout of hypercore tree files
+// The index of the node is not encoded, being implied by the position of the node
+func (dn DefaultNode) MarshalBinary() ([]byte, error) {
+	return appendNode(make([]byte, 0, EncodedNodeSize), dn)
+}
+
+// UnmarshalBinary decodes the hash and size of the node from its 40 byte encoding
+// The node keeps its existing index, which must be set beforehand, eg. by NewNode
+func (dn *DefaultNode) UnmarshalBinary(data []byte) error {
+	if len(data) != EncodedNodeSize {
+		return ErrInvalidEncoding
+	}
+
+	*dn = decodeNode(dn.index, data)
+	return nil
+}
+
+// MarshalNodes encodes a run of nodes back to back, as they are laid out in a tree file
+// The indices of the nodes are implied by their positions, so they must be consecutive
+func MarshalNodes(nodes []Node) ([]byte, error) {
+	buf := make([]byte, 0, len(nodes)*EncodedNodeSize)
+	for i, node := range nodes {
+		if node.Index() != nodes[0].Index()+uint64(i) {
+			return nil, ErrNodesNotInRun
+		}
+
+		var err error
+		if buf, err = appendNode(buf, node); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalNodes decodes a run of nodes encoded back to back, the first of which is at the provided flat tree index
+func UnmarshalNodes(index uint64, data []byte) ([]Node, error) {
+	if len(data)%EncodedNodeSize != 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	nodes := make([]Node, 0, len(data)/EncodedNodeSize)
+	for offset := 0; offset < len(data); offset += EncodedNodeSize {
+		nodes = append(nodes, decodeNode(index, data[offset:offset+EncodedNodeSize]))
+		index++
+	}
+	return nodes, nil
+}
+
+func appendNode(buf []byte, node Node) ([]byte, error) {
+	if len(node.Hash()) != HashSize {
+		return nil, ErrInvalidHashSize
+	}
+	buf = append(buf, node.Hash()...)
+	return appendUint64(buf, node.Size()), nil
+}
+
+func decodeNode(index uint64, data []byte) DefaultNode {
+	hash := make([]byte, HashSize)
+	copy(hash, data)
+	return NewNode(index, hash, binary.BigEndian.Uint64(data[HashSize:]))
+}
@@ -0,0 +1,79 @@
+package merkle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DefaultNode_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	node := NewNode(5, bytes.Repeat([]byte{0xab}, HashSize), 258)
+
+	buf, err := node.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, buf, EncodedNodeSize)
+	assert.Equal(t, node.Hash(), buf[:HashSize])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 1, 2}, buf[HashSize:])
+
+	decoded := NewNode(5, nil, 0)
+	assert.NoError(t, decoded.UnmarshalBinary(buf))
+	assert.Equal(t, node, decoded)
+
+	// the index is implied by position, so a zero node decodes as the node at index 0
+	var zero DefaultNode
+	assert.NoError(t, zero.UnmarshalBinary(buf))
+	assert.Equal(t, NewNode(0, node.Hash(), 258), zero)
+
+	assert.Equal(t, ErrInvalidEncoding, zero.UnmarshalBinary(buf[1:]))
+
+	_, err = NewNode(0, []byte("short"), 0).MarshalBinary()
+	assert.Equal(t, ErrInvalidHashSize, err)
+}
+
+func Test_MarshalNodes(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	for _, block := range []string{"a", "bc", "def", "ghij"} {
+		assert.NoError(t, stream.Append([]byte(block)))
+	}
+
+	nodes := []Node{}
+	for index := uint64(0); index < 7; index++ {
+		node, err := stream.Store().Get(index)
+		assert.NoError(t, err)
+		nodes = append(nodes, node)
+	}
+
+	buf, err := MarshalNodes(nodes)
+	assert.NoError(t, err)
+	assert.Len(t, buf, 7*EncodedNodeSize)
+
+	for i, node := range nodes {
+		encoded, err := node.(DefaultNode).MarshalBinary()
+		assert.NoError(t, err)
+		assert.Equal(t, encoded, buf[i*EncodedNodeSize:(i+1)*EncodedNodeSize])
+	}
+
+	decoded, err := UnmarshalNodes(0, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, nodes, decoded)
+
+	// a run decoded from an offset takes its indices from the first node
+	decoded, err = UnmarshalNodes(3, buf[3*EncodedNodeSize:])
+	assert.NoError(t, err)
+	assert.Equal(t, nodes[3:], decoded)
+
+	_, err = UnmarshalNodes(0, buf[1:])
+	assert.Equal(t, ErrInvalidEncoding, err)
+
+	_, err = MarshalNodes([]Node{NewLeafNode(BLAKE2b512{}, 0, []byte("a"))})
+	assert.Equal(t, ErrInvalidHashSize, err)
+
+	// a gap in the run would shift every later node into the wrong slot
+	_, err = MarshalNodes([]Node{nodes[0], nodes[1], nodes[3]})
+	assert.Equal(t, ErrNodesNotInRun, err)
+}
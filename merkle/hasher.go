@@ -1,17 +1,30 @@
 package merkle
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+
 	"golang.org/x/crypto/blake2b"
 )
 
+var ErrInvalidKey = errors.New("key must be between 1 and 64 bytes")
+
 type NodeHasher interface {
+	Algorithm() string // name of the hashing algorithm, recorded alongside persisted trees and proofs
 	Node() Node
 	HashLeaf(node PartialNode) []byte
 	HashParent(left, right Node) []byte
+	HashRoots(roots []Node) []byte // hash of the roots of a tree, identifying the state of the tree as a whole
 }
 
 type BLAKE2b512 struct{}
 
+func (b2b BLAKE2b512) Algorithm() string {
+	return "BLAKE2b-512"
+}
+
 func (b2b BLAKE2b512) Node() Node {
 	return &DefaultNode{}
 }
@@ -31,3 +44,162 @@ func (b2b BLAKE2b512) HashParent(left, right Node) []byte {
 	}
 	return hash
 }
+
+func (b2b BLAKE2b512) HashRoots(roots []Node) []byte {
+	buf := []byte{}
+	for _, root := range roots {
+		buf = append(buf, root.Hash()...)
+		buf = appendUint64(buf, root.Index())
+	}
+
+	hash := blake2b.Sum512(buf)
+	return hash[:]
+}
+
+// hypercore node type prefixes, which separate the leaf, parent and root hash domains
+var (
+	leafType   = []byte{0x00}
+	parentType = []byte{0x01}
+	rootType   = []byte{0x02}
+)
+
+// BLAKE2b256 hashes nodes as specified by hypercore, prefixing each hash with the
+// type of the node and mixing in the number of bytes spanned by the node
+type BLAKE2b256 struct{}
+
+func (b2b BLAKE2b256) Algorithm() string {
+	return "BLAKE2b"
+}
+
+func (b2b BLAKE2b256) Node() Node {
+	return &DefaultNode{}
+}
+
+// HashLeaf returns BLAKE2b-256(0x00 | uint64(size) | data)
+func (b2b BLAKE2b256) HashLeaf(node PartialNode) []byte {
+	return hashLeaf(newBLAKE2b256, node)
+}
+
+// HashParent returns BLAKE2b-256(0x01 | uint64(left.size + right.size) | left.hash | right.hash)
+func (b2b BLAKE2b256) HashParent(left, right Node) []byte {
+	return hashParent(newBLAKE2b256, left, right)
+}
+
+// HashRoots returns BLAKE2b-256(0x02 | for each root: root.hash | uint64(root.index) | uint64(root.size))
+func (b2b BLAKE2b256) HashRoots(roots []Node) []byte {
+	return hashRoots(newBLAKE2b256, roots)
+}
+
+func newBLAKE2b256() hash.Hash {
+	h, _ := blake2b.New256(nil)
+	return h
+}
+
+// SHA256 hashes nodes with the same typed, size prefixed scheme as BLAKE2b256, using SHA-256
+type SHA256 struct{}
+
+func (s SHA256) Algorithm() string {
+	return "SHA-256"
+}
+
+func (s SHA256) Node() Node {
+	return &DefaultNode{}
+}
+
+// HashLeaf returns SHA-256(0x00 | uint64(size) | data)
+func (s SHA256) HashLeaf(node PartialNode) []byte {
+	return hashLeaf(sha256.New, node)
+}
+
+// HashParent returns SHA-256(0x01 | uint64(left.size + right.size) | left.hash | right.hash)
+func (s SHA256) HashParent(left, right Node) []byte {
+	return hashParent(sha256.New, left, right)
+}
+
+// HashRoots returns SHA-256(0x02 | for each root: root.hash | uint64(root.index) | uint64(root.size))
+func (s SHA256) HashRoots(roots []Node) []byte {
+	return hashRoots(sha256.New, roots)
+}
+
+// KeyedBLAKE2b256 hashes nodes with the same scheme as BLAKE2b256, using BLAKE2b-256 keyed
+// with a secret, so that only holders of the key can build or verify the tree
+type KeyedBLAKE2b256 struct {
+	key []byte
+}
+
+// NewKeyedBLAKE2b256 constructs a hasher keyed with the provided key of 1 to 64 bytes
+func NewKeyedBLAKE2b256(key []byte) (KeyedBLAKE2b256, error) {
+	if len(key) == 0 || len(key) > blake2b.Size {
+		return KeyedBLAKE2b256{}, ErrInvalidKey
+	}
+	return KeyedBLAKE2b256{key: append([]byte{}, key...)}, nil
+}
+
+func (k KeyedBLAKE2b256) Algorithm() string {
+	return "BLAKE2b-keyed"
+}
+
+func (k KeyedBLAKE2b256) Node() Node {
+	return &DefaultNode{}
+}
+
+// HashLeaf returns BLAKE2b-256[key](0x00 | uint64(size) | data)
+func (k KeyedBLAKE2b256) HashLeaf(node PartialNode) []byte {
+	return hashLeaf(k.newHash, node)
+}
+
+// HashParent returns BLAKE2b-256[key](0x01 | uint64(left.size + right.size) | left.hash | right.hash)
+func (k KeyedBLAKE2b256) HashParent(left, right Node) []byte {
+	return hashParent(k.newHash, left, right)
+}
+
+// HashRoots returns BLAKE2b-256[key](0x02 | for each root: root.hash | uint64(root.index) | uint64(root.size))
+func (k KeyedBLAKE2b256) HashRoots(roots []Node) []byte {
+	return hashRoots(k.newHash, roots)
+}
+
+func (k KeyedBLAKE2b256) newHash() hash.Hash {
+	h, _ := blake2b.New256(k.key)
+	return h
+}
+
+// hashLeaf, hashParent and hashRoots implement the hypercore hashing scheme over any hash function
+
+func hashLeaf(newHash func() hash.Hash, node PartialNode) []byte {
+	return sum(newHash, leafType, encodeUint64(uint64(len(node.data))), node.data)
+}
+
+func hashParent(newHash func() hash.Hash, left, right Node) []byte {
+	if left.Index() > right.Index() {
+		left, right = right, left
+	}
+	size := left.Size() + right.Size()
+	return sum(newHash, parentType, encodeUint64(size), left.Hash(), right.Hash())
+}
+
+func hashRoots(newHash func() hash.Hash, roots []Node) []byte {
+	buffers := [][]byte{rootType}
+	for _, root := range roots {
+		buffers = append(buffers, root.Hash(), encodeUint64(root.Index()), encodeUint64(root.Size()))
+	}
+	return sum(newHash, buffers...)
+}
+
+func sum(newHash func() hash.Hash, buffers ...[]byte) []byte {
+	h := newHash()
+	for _, buf := range buffers {
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+// appendUint64 appends the big endian encoding of n to buf
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf, encodeUint64(n)...)
+}
+
+func encodeUint64(n uint64) []byte {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, n)
+	return encoded
+}
@@ -1,6 +1,8 @@
 package merkle
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,6 +55,22 @@ func Test_BLAKE2b512_HashParent(t *testing.T) {
 	compareBytes(t, parentHash, expected)
 }
 
+func Test_BLAKE2b512_HashRoots(t *testing.T) {
+	blake2b := BLAKE2b512{}
+
+	roots := []Node{
+		DefaultNode{index: 3, kind: parent, hash: []byte("left")},
+		DefaultNode{index: 8, kind: leaf, hash: []byte("right")},
+	}
+
+	expected := append([]byte("left"), 0, 0, 0, 0, 0, 0, 0, 3)
+	expected = append(expected, []byte("right")...)
+	expected = append(expected, 0, 0, 0, 0, 0, 0, 0, 8)
+	compareBytes(t, blake2b.HashRoots(roots), b2b.Sum512(expected))
+
+	assert.NotEqual(t, blake2b.HashRoots(roots), blake2b.HashRoots(roots[:1]))
+}
+
 func toDynamicallySizedBuffer(bytes [64]byte) []byte {
 	b := []byte{}
 	for _, byte := range bytes {
@@ -67,3 +85,151 @@ func compareBytes(t *testing.T, expected []byte, actual [64]byte) {
 		assert.Equal(t, b, actual[i])
 	}
 }
+
+func Test_BLAKE2b256_Node(t *testing.T) {
+	assert.Equal(t, &DefaultNode{}, BLAKE2b256{}.Node())
+}
+
+func Test_BLAKE2b256_HashLeaf(t *testing.T) {
+	node := PartialNode{
+		index: 0,
+		kind:  leaf,
+		data:  []byte("greetings"),
+	}
+
+	expected := b2b.Sum256(append([]byte{0x00, 0, 0, 0, 0, 0, 0, 0, 9}, []byte("greetings")...))
+	assert.Equal(t, expected[:], BLAKE2b256{}.HashLeaf(node))
+}
+
+func Test_BLAKE2b256_HashParent(t *testing.T) {
+	leftHash := b2b.Sum256([]byte("hello"))
+	rightHash := b2b.Sum256([]byte("world"))
+
+	left := DefaultNode{index: 0, kind: leaf, hash: leftHash[:], size: 5}
+	right := DefaultNode{index: 2, kind: leaf, hash: rightHash[:], size: 300}
+
+	buf := []byte{0x01, 0, 0, 0, 0, 0, 0, 0x01, 0x31}
+	buf = append(buf, leftHash[:]...)
+	buf = append(buf, rightHash[:]...)
+	expected := b2b.Sum256(buf)
+
+	assert.Equal(t, expected[:], BLAKE2b256{}.HashParent(left, right))
+	assert.Equal(t, expected[:], BLAKE2b256{}.HashParent(right, left), "children should be hashed in flat tree order")
+}
+
+func Test_BLAKE2b256_HashRoots(t *testing.T) {
+	roots := []Node{
+		DefaultNode{index: 3, kind: parent, hash: []byte("left"), size: 20},
+		DefaultNode{index: 8, kind: leaf, hash: []byte("right"), size: 4},
+	}
+
+	buf := []byte{0x02}
+	buf = append(buf, []byte("left")...)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 3)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 20)
+	buf = append(buf, []byte("right")...)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 8)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 4)
+	expected := b2b.Sum256(buf)
+
+	assert.Equal(t, expected[:], BLAKE2b256{}.HashRoots(roots))
+}
+
+// Test_BLAKE2b256_Vectors checks the hashes against fixed values of the hypercore-crypto scheme with
+// big endian sizes, rather than rebuilding them with the same encoding as the hasher
+func Test_BLAKE2b256_Vectors(t *testing.T) {
+	hasher := BLAKE2b256{}
+	data := []byte("hello world")
+
+	leafHash := hasher.HashLeaf(PartialNode{index: 0, kind: leaf, data: data, size: uint64(len(data))})
+	assert.Equal(t, "ccfa4259ee7c41e411e5770973a49c5ceffb5272d6a37f2c6f2dac2190f7e2b7", hex.EncodeToString(leafHash))
+
+	left := DefaultNode{index: 0, kind: leaf, hash: leafHash, size: 11}
+	right := DefaultNode{index: 2, kind: leaf, hash: leafHash, size: 11}
+	assert.Equal(t, "43563406adba8b34b133fdca32d0a458c5be769615e01df30e6535ccd3c075f0", hex.EncodeToString(hasher.HashParent(left, right)))
+
+	roots := []Node{
+		DefaultNode{index: 3, kind: parent, hash: make([]byte, 32), size: 11},
+		DefaultNode{index: 9, kind: parent, hash: make([]byte, 32), size: 2},
+	}
+	assert.Equal(t, "334dd9d8f9a48c7b7e60affa8704a3597f87fe645fe83f1aada3a1216ea91e65", hex.EncodeToString(hasher.HashRoots(roots)))
+}
+
+func Test_BLAKE2b256_Stream(t *testing.T) {
+	hasher := BLAKE2b256{}
+	stream := NewStream(hasher, nil, nil)
+	stream.Append([]byte("a"))
+	stream.Append([]byte("bc"))
+
+	roots := stream.Roots()
+	assert.Len(t, roots, 1)
+
+	left := hasher.Node().Build(PartialNode{index: 0, kind: leaf, data: []byte("a"), size: 1}, hasher.HashLeaf(PartialNode{data: []byte("a")}))
+	right := hasher.Node().Build(PartialNode{index: 2, kind: leaf, data: []byte("bc"), size: 2}, hasher.HashLeaf(PartialNode{data: []byte("bc")}))
+	assert.Equal(t, hasher.HashParent(left, right), roots[0].Hash())
+	assert.Equal(t, uint64(3), roots[0].Size())
+}
+
+func Test_SHA256_HashLeaf(t *testing.T) {
+	node := PartialNode{index: 0, kind: leaf, data: []byte("greetings")}
+
+	expected := sha256.Sum256(append([]byte{0x00, 0, 0, 0, 0, 0, 0, 0, 9}, []byte("greetings")...))
+	assert.Equal(t, expected[:], SHA256{}.HashLeaf(node))
+}
+
+func Test_SHA256_HashParent(t *testing.T) {
+	leftHash := sha256.Sum256([]byte("hello"))
+	rightHash := sha256.Sum256([]byte("world"))
+
+	left := DefaultNode{index: 0, kind: leaf, hash: leftHash[:], size: 5}
+	right := DefaultNode{index: 2, kind: leaf, hash: rightHash[:], size: 300}
+
+	buf := []byte{0x01, 0, 0, 0, 0, 0, 0, 0x01, 0x31}
+	buf = append(buf, leftHash[:]...)
+	buf = append(buf, rightHash[:]...)
+	expected := sha256.Sum256(buf)
+
+	assert.Equal(t, expected[:], SHA256{}.HashParent(right, left))
+}
+
+func Test_SHA256_HashRoots(t *testing.T) {
+	roots := []Node{DefaultNode{index: 1, kind: parent, hash: []byte("root"), size: 7}}
+
+	buf := []byte{0x02}
+	buf = append(buf, []byte("root")...)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 1)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 7)
+	expected := sha256.Sum256(buf)
+
+	assert.Equal(t, expected[:], SHA256{}.HashRoots(roots))
+}
+
+func Test_KeyedBLAKE2b256(t *testing.T) {
+	_, err := NewKeyedBLAKE2b256(nil)
+	assert.Equal(t, ErrInvalidKey, err)
+	_, err = NewKeyedBLAKE2b256(make([]byte, 65))
+	assert.Equal(t, ErrInvalidKey, err)
+
+	hasher, err := NewKeyedBLAKE2b256([]byte("secret"))
+	assert.NoError(t, err)
+
+	node := PartialNode{index: 0, kind: leaf, data: []byte("greetings")}
+	keyed, _ := b2b.New256([]byte("secret"))
+	keyed.Write(append([]byte{0x00, 0, 0, 0, 0, 0, 0, 0, 9}, []byte("greetings")...))
+	assert.Equal(t, keyed.Sum(nil), hasher.HashLeaf(node))
+	assert.NotEqual(t, BLAKE2b256{}.HashLeaf(node), hasher.HashLeaf(node))
+
+	other, err := NewKeyedBLAKE2b256([]byte("other"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, other.HashLeaf(node), hasher.HashLeaf(node))
+}
+
+func Test_NodeHasher_Algorithm(t *testing.T) {
+	keyed, err := NewKeyedBLAKE2b256([]byte("secret"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "BLAKE2b-512", BLAKE2b512{}.Algorithm())
+	assert.Equal(t, "BLAKE2b", BLAKE2b256{}.Algorithm())
+	assert.Equal(t, "SHA-256", SHA256{}.Algorithm())
+	assert.Equal(t, "BLAKE2b-keyed", keyed.Algorithm())
+}
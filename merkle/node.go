@@ -1,6 +1,10 @@
 package merkle
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/kiambogo/go-hypercore/flattree"
+)
 
 type nodeKind int
 
@@ -14,6 +18,7 @@ type PartialNode struct {
 	parent uint64
 	kind   nodeKind
 	data   []byte
+	size   uint64 // number of bytes spanned by the node
 }
 
 type Node interface {
@@ -21,6 +26,7 @@ type Node interface {
 	Parent() uint64
 	Kind() nodeKind
 	Hash() []byte
+	Size() uint64 // number of bytes spanned by the node; the data length of a leaf, or the sum of a parent's children
 	Build(part PartialNode, hash []byte) Node
 }
 
@@ -30,6 +36,48 @@ type DefaultNode struct {
 	kind   nodeKind
 	data   []byte
 	hash   []byte
+	size   uint64
+}
+
+// NewNode constructs a node from its flat tree index and its stored hash and size
+// The kind of the node is implied by its index, as leaves are always even
+func NewNode(index uint64, hash []byte, size uint64) DefaultNode {
+	kind := parent
+	if index%2 == 0 {
+		kind = leaf
+	}
+
+	return DefaultNode{
+		index:  index,
+		parent: flattree.Parent(index),
+		kind:   kind,
+		hash:   hash,
+		size:   size,
+	}
+}
+
+// NewLeafNode hashes data into the leaf node at the provided flat tree index
+func NewLeafNode(hasher NodeHasher, index uint64, data []byte) Node {
+	part := PartialNode{
+		index:  index,
+		parent: flattree.Parent(index),
+		kind:   leaf,
+		data:   data,
+		size:   uint64(len(data)),
+	}
+	return hasher.Node().Build(part, hasher.HashLeaf(part))
+}
+
+// NewParentNode hashes two sibling nodes into their parent node
+func NewParentNode(hasher NodeHasher, left, right Node) Node {
+	part := PartialNode{
+		index:  left.Parent(),
+		parent: flattree.Parent(left.Parent()),
+		kind:   parent,
+		data:   nil,
+		size:   left.Size() + right.Size(),
+	}
+	return hasher.Node().Build(part, hasher.HashParent(left, right))
 }
 
 func (dn DefaultNode) Index() uint64 {
@@ -47,6 +95,9 @@ func (dn DefaultNode) Kind() nodeKind {
 func (dn DefaultNode) Hash() []byte {
 	return dn.hash
 }
+func (dn DefaultNode) Size() uint64 {
+	return dn.size
+}
 func (dn DefaultNode) Build(part PartialNode, hash []byte) Node {
 	return DefaultNode{
 		index:  part.index,
@@ -54,9 +105,10 @@ func (dn DefaultNode) Build(part PartialNode, hash []byte) Node {
 		kind:   part.kind,
 		data:   part.data,
 		hash:   hash,
+		size:   part.size,
 	}
 }
 
 func (dn DefaultNode) String() string {
-	return fmt.Sprintf("{Index: %d, Data: %s}", dn.index, dn.data)
+	return fmt.Sprintf("{Index: %d, Size: %d, Data: %s}", dn.index, dn.size, dn.data)
 }
@@ -0,0 +1,74 @@
+package merkle
+
+import (
+	"sync"
+
+	"github.com/kiambogo/go-hypercore/flattree"
+)
+
+// NodeStore holds the nodes of a merkle tree by flat tree index
+// Stores may buffer the nodes put into them until they are flushed
+type NodeStore interface {
+	Get(index uint64) (Node, error) // returns ErrMissingNode if the store does not hold the node
+	Put(node Node) error
+	Flush() error                 // persists every node put since the last flush
+	Truncate(length uint64) error // drops every node spanning a block at or after length
+}
+
+// MemoryNodeStore is a NodeStore holding the hash and size of each node in memory
+// The data of leaf nodes is not retained
+type MemoryNodeStore struct {
+	nodes map[uint64]Node
+	mu    *sync.RWMutex
+}
+
+func NewMemoryNodeStore() *MemoryNodeStore {
+	return &MemoryNodeStore{
+		nodes: map[uint64]Node{},
+		mu:    &sync.RWMutex{},
+	}
+}
+
+func (m *MemoryNodeStore) Get(index uint64) (Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, ok := m.nodes[index]
+	if !ok {
+		return nil, ErrMissingNode
+	}
+	return node, nil
+}
+
+func (m *MemoryNodeStore) Put(node Node) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nodes[node.Index()] = NewNode(node.Index(), node.Hash(), node.Size())
+	return nil
+}
+
+// Flush is a no-op, as nodes are held as soon as they are put
+func (m *MemoryNodeStore) Flush() error {
+	return nil
+}
+
+func (m *MemoryNodeStore) Truncate(length uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for index := range m.nodes {
+		if flattree.RightSpan(index) >= length*2 {
+			delete(m.nodes, index)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of nodes held by the store
+func (m *MemoryNodeStore) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.nodes)
+}
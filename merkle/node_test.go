@@ -0,0 +1,50 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewNode(t *testing.T) {
+	t.Parallel()
+
+	node := NewNode(4, []byte("hash"), 12)
+	assert.Equal(t, uint64(4), node.Index())
+	assert.Equal(t, uint64(5), node.Parent())
+	assert.Equal(t, leaf, node.Kind())
+	assert.Equal(t, []byte("hash"), node.Hash())
+	assert.Equal(t, uint64(12), node.Size())
+	assert.Nil(t, node.Data())
+
+	node = NewNode(3, []byte("hash"), 40)
+	assert.Equal(t, uint64(7), node.Parent())
+	assert.Equal(t, parent, node.Kind())
+	assert.Equal(t, uint64(40), node.Size())
+}
+
+func Test_NewLeafAndParentNode(t *testing.T) {
+	t.Parallel()
+
+	hasher := BLAKE2b256{}
+	left := NewLeafNode(hasher, 0, []byte("hello"))
+	right := NewLeafNode(hasher, 2, []byte("world!"))
+
+	assert.Equal(t, uint64(0), left.Index())
+	assert.Equal(t, uint64(1), left.Parent())
+	assert.Equal(t, leaf, left.Kind())
+	assert.Equal(t, uint64(5), left.Size())
+	assert.Equal(t, hasher.HashLeaf(PartialNode{data: []byte("hello")}), left.Hash())
+
+	parentNode := NewParentNode(hasher, left, right)
+	assert.Equal(t, uint64(1), parentNode.Index())
+	assert.Equal(t, uint64(3), parentNode.Parent())
+	assert.Equal(t, parent, parentNode.Kind())
+	assert.Equal(t, uint64(11), parentNode.Size())
+	assert.Equal(t, hasher.HashParent(left, right), parentNode.Hash())
+
+	stream := NewStream(hasher, nil, nil)
+	stream.Append([]byte("hello"))
+	stream.Append([]byte("world!"))
+	assert.Equal(t, parentNode, stream.Roots()[0])
+}
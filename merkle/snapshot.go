@@ -0,0 +1,121 @@
+package merkle
+
+import (
+	"errors"
+
+	"github.com/kiambogo/go-hypercore/flattree"
+)
+
+var (
+	ErrSnapshotBounds    = errors.New("node spans blocks beyond the length of the snapshot")
+	ErrSnapshotTruncated = errors.New("stream was truncated after the snapshot was taken")
+)
+
+// Snapshot is an immutable view of a stream at a point in time
+// A snapshot is safe to query while the stream continues to be appended to, as the nodes of the tree
+// at its length are never rewritten by appends
+// Once the stream is truncated, its nodes may belong to another fork, so node and proof queries
+// fail with ErrSnapshotTruncated rather than serve them
+type Snapshot struct {
+	hasher    NodeHasher
+	roots     []Node
+	blocks    uint64
+	stream    *stream
+	truncated uint64 // truncations of the stream at the time of the snapshot
+}
+
+// Snapshot captures the current roots and length of the stream
+func (s *stream) Snapshot() Snapshot {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	return Snapshot{
+		hasher:    s.NodeHasher,
+		roots:     append([]Node{}, *s.roots...),
+		blocks:    s.blocks,
+		stream:    s,
+		truncated: s.truncated,
+	}
+}
+
+// Roots returns the roots of the tree at the time of the snapshot
+func (sn Snapshot) Roots() []Node {
+	return append([]Node{}, sn.roots...)
+}
+
+// Blocks returns the number of blocks in the tree at the time of the snapshot
+func (sn Snapshot) Blocks() uint64 {
+	return sn.blocks
+}
+
+// TreeHash returns the hash of the roots of the snapshot
+func (sn Snapshot) TreeHash() []byte {
+	return sn.hasher.HashRoots(sn.roots)
+}
+
+// Signable returns the payload to sign for the snapshot, on the provided fork
+func (sn Snapshot) Signable(fork uint64) []byte {
+	return Signable(sn.TreeHash(), sn.blocks, fork)
+}
+
+// Node returns the node at the provided flat tree index, which must only span blocks within the snapshot
+func (sn Snapshot) Node(index uint64) (Node, error) {
+	if flattree.RightSpan(index) >= sn.blocks*2 {
+		return nil, ErrSnapshotBounds
+	}
+	return sn.get(index)
+}
+
+// Proof returns the nodes proving the block at the provided index against the roots of the snapshot:
+// the sibling of each node on the path from the block's leaf up to its root, in that order
+func (sn Snapshot) Proof(block uint64) ([]Node, error) {
+	if block >= sn.blocks {
+		return nil, ErrSnapshotBounds
+	}
+	if err := sn.checkTruncated(); err != nil {
+		return nil, err
+	}
+
+	roots, err := flattree.FullRoots(sn.blocks * 2)
+	if err != nil {
+		return nil, err
+	}
+	isRoot := map[uint64]bool{}
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+
+	proof := []Node{}
+	for index := block * 2; !isRoot[index]; index = flattree.Parent(index) {
+		sibling, err := sn.get(flattree.Sibling(index))
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, sibling)
+	}
+
+	return proof, nil
+}
+
+// checkTruncated fails if the stream was truncated since the snapshot was taken
+func (sn Snapshot) checkTruncated() error {
+	sn.stream.wg.Lock()
+	defer sn.stream.wg.Unlock()
+
+	if sn.stream.truncated != sn.truncated {
+		return ErrSnapshotTruncated
+	}
+	return nil
+}
+
+// get reads the node from the store of the stream, holding the lock of the stream so that
+// no truncation can drop the node between checking for truncations and reading it
+func (sn Snapshot) get(index uint64) (Node, error) {
+	sn.stream.wg.Lock()
+	defer sn.stream.wg.Unlock()
+
+	if sn.stream.truncated != sn.truncated {
+		return nil, ErrSnapshotTruncated
+	}
+	return sn.stream.store.Get(index)
+}
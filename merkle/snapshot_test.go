@@ -0,0 +1,158 @@
+package merkle
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+	}
+
+	snapshot := stream.Snapshot()
+	roots := snapshot.Roots()
+	treeHash := snapshot.TreeHash()
+
+	assert.NoError(t, stream.Append([]byte("5")))
+	assert.NotEqual(t, treeHash, stream.TreeHash())
+
+	// the snapshot is unaffected by later appends
+	assert.Equal(t, uint64(5), snapshot.Blocks())
+	assert.Equal(t, roots, snapshot.Roots())
+	assert.Equal(t, treeHash, snapshot.TreeHash())
+	assert.Equal(t, Signable(treeHash, 5, 1), snapshot.Signable(1))
+
+	node, err := snapshot.Node(3)
+	assert.NoError(t, err)
+	assert.Equal(t, roots[0].Hash(), node.Hash())
+
+	// node 9 exists in the stream, but spans block 5 which the snapshot does not hold
+	_, err = stream.Store().Get(9)
+	assert.NoError(t, err)
+	_, err = snapshot.Node(9)
+	assert.Equal(t, ErrSnapshotBounds, err)
+
+	for block := uint64(0); block < 5; block++ {
+		proof, err := snapshot.Proof(block)
+		assert.NoError(t, err)
+		assertProves(t, BLAKE2b256{}, NewLeafNode(BLAKE2b256{}, block*2, []byte(fmt.Sprint(block))), proof, roots)
+	}
+
+	_, err = snapshot.Proof(5)
+	assert.Equal(t, ErrSnapshotBounds, err)
+}
+
+func Test_Snapshot_Truncated(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+	}
+	snapshot := stream.Snapshot()
+	roots := snapshot.Roots()
+
+	// truncating to the current length drops nothing
+	assert.NoError(t, stream.Truncate(5))
+	_, err := snapshot.Node(6)
+	assert.NoError(t, err)
+
+	// leaf 6 is rewritten on the new fork, so the snapshot no longer serves any node
+	assert.NoError(t, stream.Truncate(3))
+	assert.NoError(t, stream.Append([]byte("fork")))
+	_, err = snapshot.Node(6)
+	assert.Equal(t, ErrSnapshotTruncated, err)
+	_, err = snapshot.Node(0)
+	assert.Equal(t, ErrSnapshotTruncated, err)
+	_, err = snapshot.Proof(0)
+	assert.Equal(t, ErrSnapshotTruncated, err)
+	_, err = snapshot.Proof(4)
+	assert.Equal(t, ErrSnapshotTruncated, err)
+
+	// the captured roots remain those of the snapshot
+	assert.Equal(t, roots, snapshot.Roots())
+
+	_, err = stream.Snapshot().Node(6)
+	assert.NoError(t, err)
+}
+
+func Test_Snapshot_ConcurrentAppends(t *testing.T) {
+	t.Parallel()
+
+	hasher := BLAKE2b256{}
+	stream := NewStream(hasher, nil, nil)
+	assert.NoError(t, stream.Append([]byte("0")))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 200; i++ {
+			assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		snapshot := stream.Snapshot()
+		block := uint64(i) % snapshot.Blocks()
+
+		proof, err := snapshot.Proof(block)
+		assert.NoError(t, err)
+		assertProves(t, hasher, NewLeafNode(hasher, block*2, []byte(fmt.Sprint(block))), proof, snapshot.Roots())
+	}
+	wg.Wait()
+}
+
+func Test_Stream_ConcurrentReads(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+		}
+	}()
+
+	// the roots are copied under the lock, so may be read while appending
+	for i := 0; i < 200; i++ {
+		roots := stream.Roots()
+		if len(roots) > 0 {
+			assert.NotNil(t, roots[len(roots)-1].Hash())
+		}
+		assert.NotNil(t, stream.Store())
+	}
+	wg.Wait()
+
+	roots := stream.Roots()
+	roots[0] = nil
+	assert.NotNil(t, stream.Roots()[0], "mutating the returned roots should not affect the stream")
+}
+
+// assertProves checks that climbing from the leaf through the proof reaches one of the roots
+func assertProves(t *testing.T, hasher NodeHasher, leaf Node, proof []Node, roots []Node) {
+	known := leaf
+	for _, sibling := range proof {
+		assert.Equal(t, flattree.Sibling(known.Index()), sibling.Index())
+		known = NewParentNode(hasher, known, sibling)
+	}
+
+	for _, root := range roots {
+		if root.Index() == known.Index() {
+			assert.Equal(t, root.Hash(), known.Hash())
+			return
+		}
+	}
+	assert.Fail(t, "proof does not reach a root", "leaf %d", leaf.Index())
+}
@@ -1,64 +1,172 @@
 package merkle
 
 import (
+	"errors"
+	"runtime"
 	"sync"
 
 	"github.com/kiambogo/go-hypercore/flattree"
 )
 
 type stream struct {
-	NodeHasher         // hashing implementation to use when building the merkle tree
-	roots      *[]Node // the current set of root nodes in the tree
-	nodes      *[]Node // the current set of all nodes in the tree
-	blocks     uint64  // size of the tree (might be ok to just len(nodes))
+	NodeHasher           // hashing implementation to use when building the merkle tree
+	roots      *[]Node   // the current set of root nodes in the tree
+	store      NodeStore // holds every node of the tree, so that only the roots are kept by the stream
+	blocks     uint64    // number of blocks in the tree
+	truncated  uint64    // number of truncations dropping blocks, each invalidating the snapshots taken before it
 	wg         *sync.Mutex
 }
 
-func NewStream(hasher NodeHasher, roots *[]Node, nodes *[]Node) *stream {
+var (
+	ErrInvalidRoots   = errors.New("roots do not form the full roots of a tree")
+	ErrTruncateLength = errors.New("cannot truncate a stream to a length greater than its own")
+	ErrMissingNode    = errors.New("node is not held by the node store")
+)
+
+// NewStream constructs a stream building on the provided roots, storing new nodes in the provided store
+// A nil store defaults to a MemoryNodeStore
+func NewStream(hasher NodeHasher, roots *[]Node, store NodeStore) *stream {
 	if roots == nil {
 		roots = new([]Node)
 	}
-	if nodes == nil {
-		nodes = new([]Node)
+	if store == nil {
+		store = NewMemoryNodeStore()
 	}
 	return &stream{
 		NodeHasher: hasher,
 		roots:      roots,
-		nodes:      nodes,
-		blocks:     0,
+		store:      store,
+		blocks:     blocksSpanned(*roots),
 		wg:         &sync.Mutex{},
 	}
 }
 
-func (s stream) Roots() *[]Node {
-	return s.roots
+// ResumeStream reconstructs a stream from the persisted roots of a tree
+// The roots must be the full roots of a tree, in order, as given by flattree.FullRoots
+func ResumeStream(hasher NodeHasher, roots []Node, store NodeStore) (*stream, error) {
+	expected, err := flattree.FullRoots(blocksSpanned(roots) * 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) != len(roots) {
+		return nil, ErrInvalidRoots
+	}
+	for i, root := range roots {
+		if root.Index() != expected[i] {
+			return nil, ErrInvalidRoots
+		}
+	}
+
+	resumed := append([]Node{}, roots...)
+	return NewStream(hasher, &resumed, store), nil
+}
+
+// blocksSpanned returns the number of blocks spanned by a set of roots,
+// being one past the right most leaf spanned by the last root
+func blocksSpanned(roots []Node) uint64 {
+	if len(roots) == 0 {
+		return 0
+	}
+
+	_, right := flattree.Spans(roots[len(roots)-1].Index())
+	return right/2 + 1
+}
+
+// Roots returns a copy of the current roots of the tree
+// Use Snapshot to read the roots consistently with the length and nodes of the tree
+func (s *stream) Roots() []Node {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	return append([]Node{}, *s.roots...)
 }
 
-func (s stream) Nodes() *[]Node {
-	return s.nodes
+func (s *stream) Store() NodeStore {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	return s.store
 }
 
-func (s stream) Blocks() uint64 {
+func (s *stream) Blocks() uint64 {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
 	return s.blocks
 }
 
-func (s *stream) Append(data []byte) {
+// TreeHash returns the hash of the current roots of the tree, identifying its state as a whole
+func (s *stream) TreeHash() []byte {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	return s.HashRoots(*s.roots)
+}
+
+// Signable returns the payload to sign for the current state of the tree, on the provided fork
+func (s *stream) Signable(fork uint64) []byte {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	return Signable(s.HashRoots(*s.roots), s.blocks, fork)
+}
+
+// Signable returns a payload binding a tree state to its length and fork: the tree hash, followed by
+// the length of the tree and its fork id as big endian uint64s
+// This is not the hypercore v10 signable, and feeds sign the bare tree hash as hypercore v9 does
+func Signable(treeHash []byte, length, fork uint64) []byte {
+	signable := append([]byte{}, treeHash...)
+	signable = appendUint64(signable, length)
+	return appendUint64(signable, fork)
+}
+
+func (s *stream) Append(data []byte) error {
 	// apply a mutex lock for stream thread safety
 	s.wg.Lock()
 	defer s.wg.Unlock()
 
 	// construct new node with data from the method argument
-	index := uint64(s.blocks * 2)
-	leafPartial := PartialNode{
-		index:  index,
-		parent: flattree.Parent(index),
-		data:   data,
-		kind:   leaf,
+	if err := s.appendLeaf(NewLeafNode(s.NodeHasher, s.blocks*2, data)); err != nil {
+		return err
 	}
-	leaf := s.Node().Build(leafPartial, s.HashLeaf(leafPartial))
+	return s.store.Flush()
+}
 
+// AppendBatch appends each of the blocks to the stream, in order
+// The leaves are hashed concurrently, without holding the lock, before being folded into the tree,
+// producing the same tree as appending each block individually
+// The new nodes are flushed to the store once, after the whole batch is appended
+func (s *stream) AppendBatch(blocks [][]byte) error {
+	s.wg.Lock()
+	firstBlock := s.blocks
+	s.wg.Unlock()
+
+	leaves := hashLeaves(s.NodeHasher, firstBlock, blocks)
+
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	// another append or truncate moved the end of the stream while hashing, so the leaves
+	// are rebuilt at their actual indices
+	if s.blocks != firstBlock {
+		leaves = hashLeaves(s.NodeHasher, s.blocks, blocks)
+	}
+
+	for _, leaf := range leaves {
+		if err := s.appendLeaf(leaf); err != nil {
+			return err
+		}
+	}
+	return s.store.Flush()
+}
+
+// appendLeaf adds a hashed leaf to the tree, building every parent it completes
+// Must be called while holding the stream lock
+func (s *stream) appendLeaf(leaf Node) error {
+	if err := s.store.Put(leaf); err != nil {
+		return err
+	}
 	*s.roots = append(*s.roots, leaf)
-	*s.nodes = append(*s.nodes, leaf)
 	s.blocks++
 
 	for len(*s.roots) > 1 {
@@ -71,20 +179,91 @@ func (s *stream) Append(data []byte) {
 		}
 
 		// construct a new parent node
-		newParentPart := PartialNode{
-			index:  left.Parent(),
-			parent: flattree.Parent(left.Parent()),
-			data:   nil,
-			kind:   parent,
-		}
-
-		newParent := s.Node().Build(newParentPart, s.HashParent(left, right))
+		newParent := NewParentNode(s.NodeHasher, left, right)
 
 		// remove the last two elements of the roots
 		*s.roots = (*s.roots)[:len(*s.roots)-2]
 
-		// append new or rehashed parent node to roots and nodes
+		// append new or rehashed parent node to roots and the store
 		*s.roots = append(*s.roots, newParent)
-		*s.nodes = append(*s.nodes, newParent)
+		if err := s.store.Put(newParent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// minBlocksPerWorker is the fewest blocks worth handing to a separate goroutine to hash
+const minBlocksPerWorker = 64
+
+// hashLeaves hashes the blocks into leaves starting at the provided block index,
+// splitting the blocks between up to GOMAXPROCS goroutines
+func hashLeaves(hasher NodeHasher, firstBlock uint64, blocks [][]byte) []Node {
+	leaves := make([]Node, len(blocks))
+
+	workers := runtime.GOMAXPROCS(0)
+	if maxWorkers := len(blocks) / minBlocksPerWorker; maxWorkers < workers {
+		workers = maxWorkers
+	}
+	if workers < 1 {
+		workers = 1
 	}
+
+	chunkSize := (len(blocks) + workers - 1) / workers
+	wg := sync.WaitGroup{}
+	for start := 0; start < len(blocks); start += chunkSize {
+		end := start + chunkSize
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				leaves[i] = NewLeafNode(hasher, (firstBlock+uint64(i))*2, blocks[i])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return leaves
+}
+
+// Truncate drops all blocks at or after the provided length from the stream, along with every node
+// spanning them in the store, and loads the roots of the remaining tree from the store
+func (s *stream) Truncate(length uint64) error {
+	s.wg.Lock()
+	defer s.wg.Unlock()
+
+	if length > s.blocks {
+		return ErrTruncateLength
+	}
+
+	rootIndices, err := flattree.FullRoots(length * 2)
+	if err != nil {
+		return err
+	}
+
+	roots := []Node{}
+	for _, index := range rootIndices {
+		root, err := s.store.Get(index)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, root)
+	}
+
+	if err := s.store.Truncate(length); err != nil {
+		return err
+	}
+
+	if length < s.blocks {
+		s.truncated++
+	}
+	*s.roots = roots
+	s.blocks = length
+
+	return nil
 }
@@ -2,6 +2,7 @@ package merkle
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,10 +15,10 @@ func Test_NewStream_Empty(t *testing.T) {
 
 	stream := NewStream(blake2bHasher, nil, nil)
 
-	assert.Equal(t, stream.Roots(), new([]Node))
+	assert.Empty(t, stream.Roots())
 }
 
-func Test_NewStream_SetRootsAndNodes(t *testing.T) {
+func Test_NewStream_SetRootsAndStore(t *testing.T) {
 	t.Parallel()
 
 	roots := &[]Node{
@@ -37,9 +38,57 @@ func Test_NewStream_SetRootsAndNodes(t *testing.T) {
 		},
 	}
 
-	stream := NewStream(blake2bHasher, roots, roots)
-	assert.Equal(t, stream.Roots(), roots)
-	assert.Equal(t, stream.Nodes(), roots)
+	store := NewMemoryNodeStore()
+	stream := NewStream(blake2bHasher, roots, store)
+	assert.Equal(t, *roots, stream.Roots())
+	assert.Equal(t, store, stream.Store())
+	assert.Equal(t, uint64(2), stream.Blocks())
+}
+
+func Test_ResumeStream(t *testing.T) {
+	t.Parallel()
+
+	original := NewStream(blake2bHasher, nil, nil)
+	for i := 0; i < 5; i++ {
+		original.Append([]byte(fmt.Sprint(i)))
+	}
+
+	stream, err := ResumeStream(blake2bHasher, original.Roots(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), stream.Blocks())
+	assert.Equal(t, original.Roots(), stream.Roots())
+
+	for i := 5; i < 11; i++ {
+		original.Append([]byte(fmt.Sprint(i)))
+		stream.Append([]byte(fmt.Sprint(i)))
+	}
+	assert.Equal(t, original.Blocks(), stream.Blocks())
+	assert.Equal(t, original.Roots(), stream.Roots())
+
+	stream, err = ResumeStream(blake2bHasher, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), stream.Blocks())
+}
+
+func Test_ResumeStream_InvalidRoots(t *testing.T) {
+	t.Parallel()
+
+	testCases := [][]uint64{
+		{1, 2},    // leaf should have joined its sibling under a parent
+		{0, 2},    // two sibling leaves are not full roots
+		{1, 6},    // gap between the roots
+		{8, 3},    // roots out of order
+		{3, 5, 8}, // overlapping roots
+	}
+
+	for _, indices := range testCases {
+		roots := []Node{}
+		for _, index := range indices {
+			roots = append(roots, NewNode(index, []byte{}, 0))
+		}
+		_, err := ResumeStream(blake2bHasher, roots, nil)
+		assert.Equal(t, ErrInvalidRoots, err, "roots %v", indices)
+	}
 }
 
 func Test_NewStream_Append(t *testing.T) {
@@ -60,9 +109,225 @@ func Test_NewStream_Append(t *testing.T) {
 	checkNodeCounts(t, 4, 3, stream)
 }
 
+func Test_NewStream_AppendSizes(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(blake2bHasher, nil, nil)
+	stream.Append([]byte("a"))
+	stream.Append([]byte("bc"))
+	stream.Append([]byte("def"))
+	stream.Append([]byte("ghij"))
+
+	for index, size := range map[uint64]uint64{0: 1, 2: 2, 1: 3, 4: 3, 6: 4, 5: 7, 3: 10} {
+		node, err := stream.Store().Get(index)
+		assert.NoError(t, err)
+		assert.Equal(t, size, node.Size(), "size of node %d", index)
+	}
+	assert.Equal(t, uint64(10), stream.Roots()[0].Size())
+}
+
+func Test_Stream_Truncate(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(blake2bHasher, nil, nil)
+	expected := NewStream(blake2bHasher, nil, nil)
+	for i := 0; i < 7; i++ {
+		stream.Append([]byte(fmt.Sprint(i)))
+		if i < 3 {
+			expected.Append([]byte(fmt.Sprint(i)))
+		}
+	}
+
+	assert.Equal(t, ErrTruncateLength, stream.Truncate(8))
+
+	assert.NoError(t, stream.Truncate(3))
+	assert.Equal(t, uint64(3), stream.Blocks())
+	assertSameNodes(t, expected.Roots(), stream.Roots())
+
+	// the nodes spanning the dropped blocks are gone from the store
+	checkNodeCounts(t, 3, 1, stream)
+	_, err := stream.Store().Get(5)
+	assert.Equal(t, ErrMissingNode, err)
+
+	// appending after truncation rebuilds the same tree as appending without it
+	assert.NoError(t, stream.Append([]byte("fork")))
+	assert.NoError(t, expected.Append([]byte("fork")))
+	assertSameNodes(t, expected.Roots(), stream.Roots())
+	for _, index := range []uint64{5, 6} {
+		node, err := stream.Store().Get(index)
+		assert.NoError(t, err)
+		expectedNode, err := expected.Store().Get(index)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedNode, node)
+	}
+
+	assert.NoError(t, stream.Truncate(0))
+	assert.Equal(t, uint64(0), stream.Blocks())
+	assert.Empty(t, stream.Roots())
+	assert.Equal(t, 0, stream.Store().(*MemoryNodeStore).Len())
+}
+
+func Test_Stream_TruncateMissingNodes(t *testing.T) {
+	t.Parallel()
+
+	original := NewStream(blake2bHasher, nil, nil)
+	for i := 0; i < 4; i++ {
+		original.Append([]byte(fmt.Sprint(i)))
+	}
+
+	// a resumed stream only holds its roots
+	stream, err := ResumeStream(blake2bHasher, original.Roots(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrMissingNode, stream.Truncate(2))
+	assert.Equal(t, uint64(4), stream.Blocks())
+}
+
+func Test_Stream_TreeHash(t *testing.T) {
+	t.Parallel()
+
+	hasher := BLAKE2b256{}
+	stream := NewStream(hasher, nil, nil)
+	assert.Equal(t, hasher.HashRoots([]Node{}), stream.TreeHash())
+
+	for i := 0; i < 5; i++ {
+		stream.Append([]byte(fmt.Sprint(i)))
+	}
+	treeHash := stream.TreeHash()
+	assert.Equal(t, hasher.HashRoots(stream.Roots()), treeHash)
+
+	stream.Append([]byte("5"))
+	assert.NotEqual(t, treeHash, stream.TreeHash())
+}
+
+func Test_Stream_Signable(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	stream.Append([]byte("a"))
+	stream.Append([]byte("b"))
+	stream.Append([]byte("c"))
+
+	signable := stream.Signable(258)
+	assert.Len(t, signable, 48)
+	assert.Equal(t, stream.TreeHash(), signable[:32])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 3}, signable[32:40])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 1, 2}, signable[40:])
+	assert.Equal(t, Signable(stream.TreeHash(), 3, 258), signable)
+	assert.NotEqual(t, signable, stream.Signable(259))
+}
+
+func Test_Stream_AppendBatch(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{0, 1, 5, 64, 1000} {
+		blocks := [][]byte{}
+		for i := 0; i < size; i++ {
+			blocks = append(blocks, []byte(fmt.Sprint(i)))
+		}
+
+		sequential := NewStream(blake2bHasher, nil, nil)
+		sequential.Append([]byte("existing"))
+		for _, block := range blocks {
+			sequential.Append(block)
+		}
+
+		batched := NewStream(blake2bHasher, nil, nil)
+		batched.Append([]byte("existing"))
+		batched.AppendBatch(blocks)
+
+		assert.Equal(t, sequential.Blocks(), batched.Blocks(), "batch of %d", size)
+		assert.Equal(t, sequential.Roots(), batched.Roots(), "batch of %d", size)
+		assert.Equal(t, sequential.Store(), batched.Store(), "batch of %d", size)
+	}
+}
+
+func Test_Stream_AppendBatchConcurrent(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(blake2bHasher, nil, nil)
+
+	// the leaves are hashed outside the lock, so concurrent batches may race to the end of the stream
+	wg := sync.WaitGroup{}
+	for batch := 0; batch < 4; batch++ {
+		blocks := [][]byte{}
+		for i := 0; i < 100; i++ {
+			blocks = append(blocks, []byte(fmt.Sprint(batch, i)))
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, stream.AppendBatch(blocks))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(400), stream.Blocks())
+	for block := uint64(0); block < 400; block++ {
+		leaf, err := stream.Store().Get(block * 2)
+		assert.NoError(t, err)
+		assert.Equal(t, block*2, leaf.Index())
+	}
+	assert.Len(t, stream.Roots(), 3)
+}
+
+func Test_Stream_StoreFlush(t *testing.T) {
+	t.Parallel()
+
+	store := &countingStore{NodeStore: NewMemoryNodeStore()}
+	stream := NewStream(blake2bHasher, nil, store)
+
+	assert.NoError(t, stream.AppendBatch([][]byte{[]byte("a"), []byte("b"), []byte("c")}))
+	assert.Equal(t, 1, store.flushes)
+	assert.NoError(t, stream.Append([]byte("d")))
+	assert.Equal(t, 2, store.flushes)
+
+	// the stream keeps only its roots, reading every other node from the store
+	assert.Len(t, stream.Roots(), 1)
+	assert.Equal(t, 7, store.NodeStore.(*MemoryNodeStore).Len())
+}
+
+func Test_MemoryNodeStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryNodeStore()
+	_, err := store.Get(0)
+	assert.Equal(t, ErrMissingNode, err)
+
+	leafNode := NewLeafNode(BLAKE2b256{}, 2, []byte("hello"))
+	assert.NoError(t, store.Put(leafNode))
+	assert.NoError(t, store.Flush())
+
+	node, err := store.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, NewNode(2, leafNode.Hash(), 5), node)
+	assert.Nil(t, node.(DefaultNode).Data(), "leaf data should not be retained")
+	assert.Equal(t, 1, store.Len())
+}
+
+// countingStore counts the flushes of the store it wraps
+type countingStore struct {
+	NodeStore
+	flushes int
+}
+
+func (c *countingStore) Flush() error {
+	c.flushes++
+	return c.NodeStore.Flush()
+}
+
+func assertSameNodes(t *testing.T, expected, actual []Node) {
+	assert.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Index(), actual[i].Index())
+		assert.Equal(t, expected[i].Hash(), actual[i].Hash())
+		assert.Equal(t, expected[i].Size(), actual[i].Size())
+	}
+}
+
 func checkNodeCounts(t *testing.T, expectedLeafs, expectedParents int, stream *stream) {
 	var leafNodes, parentNodes = 0, 0
-	for _, n := range *stream.nodes {
+	for _, n := range stream.store.(*MemoryNodeStore).nodes {
 		if n.Kind() == leaf {
 			leafNodes++
 		} else {
@@ -85,6 +350,18 @@ func Benchmark_Blake2BStream10000(b *testing.B) {
 	benchmarkBlake2BStream(10000, b)
 }
 
+func Benchmark_Blake2BStreamBatch10000(b *testing.B) {
+	blocks := [][]byte{}
+	for j := 0; j < 10000; j++ {
+		blocks = append(blocks, []byte(fmt.Sprint(j)))
+	}
+
+	for n := 0; n < b.N; n++ {
+		stream := NewStream(blake2bHasher, nil, nil)
+		stream.AppendBatch(blocks)
+	}
+}
+
 func benchmarkBlake2BStream(i int, b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		stream := NewStream(blake2bHasher, nil, nil)
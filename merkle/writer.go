@@ -0,0 +1,110 @@
+package merkle
+
+import "errors"
+
+var (
+	ErrInvalidBlockSize = errors.New("block size must be positive")
+	ErrWriterClosed     = errors.New("writer is closed")
+)
+
+// BlockAppender appends a batch of blocks to a log, as implemented by the merkle stream
+type BlockAppender interface {
+	AppendBatch(blocks [][]byte) error
+}
+
+// AppendFunc adapts a function into a BlockAppender
+type AppendFunc func(blocks [][]byte) error
+
+func (fn AppendFunc) AppendBatch(blocks [][]byte) error {
+	return fn(blocks)
+}
+
+// splitFunc returns the length of the first block within buf, or 0 if buf does not hold a complete block
+// The first scanned bytes of buf were already searched for a boundary by an earlier call, so the search resumes after them
+type splitFunc func(buf []byte, scanned int) int
+
+// Writer is an io.Writer which buffers its input, appending each complete block to the underlying appender
+// The final partial block is only appended by Flush or Close
+type Writer struct {
+	appender BlockAppender
+	split    splitFunc // finds the boundary of the next block within the buffer
+	buf      []byte    // input not yet appended as a block, owned by the writer
+	scanned  int       // length of buf already searched for a block boundary
+	err      error     // the first error encountered, returned by every later call
+	closed   bool
+}
+
+// NewWriter constructs a writer appending blocks of blockSize bytes to the appender
+func NewWriter(appender BlockAppender, blockSize int) (*Writer, error) {
+	if blockSize <= 0 {
+		return nil, ErrInvalidBlockSize
+	}
+
+	split := func(buf []byte, scanned int) int {
+		if len(buf) < blockSize {
+			return 0
+		}
+		return blockSize
+	}
+	return &Writer{appender: appender, split: split}, nil
+}
+
+// Write buffers p, appending every block it completes in a single batch
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+	buf := w.buf
+	blocks := [][]byte{}
+	for n := w.split(buf, w.scanned); n > 0; n = w.split(buf, w.scanned) {
+		blocks = append(blocks, buf[:n])
+		buf = buf[n:]
+		w.scanned = 0
+	}
+	w.scanned = len(buf)
+	if len(blocks) == 0 {
+		return len(p), nil
+	}
+
+	// the appended blocks may be retained and alias the buffer, so only then is the remainder copied out of it
+	w.buf = append([]byte(nil), buf...)
+
+	if w.err = w.appender.AppendBatch(blocks); w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// Flush appends any buffered input as a final, partial block
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	if w.err = w.appender.AppendBatch([][]byte{w.buf}); w.err != nil {
+		return w.err
+	}
+	w.buf = nil
+	w.scanned = 0
+	return nil
+}
+
+// Close flushes any buffered input and closes the writer, without closing the underlying appender
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+	return nil
+}
@@ -0,0 +1,115 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Writer_Blocks(t *testing.T) {
+	t.Parallel()
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	writer, err := NewWriter(stream, 4)
+	assert.NoError(t, err)
+
+	n, err := writer.Write([]byte("ab"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, uint64(0), stream.Blocks())
+
+	_, err = writer.Write([]byte("cdefghijk"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), stream.Blocks())
+
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, uint64(3), stream.Blocks())
+
+	expected := NewStream(BLAKE2b256{}, nil, nil)
+	assert.NoError(t, expected.AppendBatch([][]byte{[]byte("abcd"), []byte("efgh"), []byte("ijk")}))
+	assert.Equal(t, expected.Roots(), stream.Roots())
+
+	_, err = writer.Write([]byte("l"))
+	assert.Equal(t, ErrWriterClosed, err)
+	assert.Equal(t, ErrWriterClosed, writer.Close())
+}
+
+func Test_Writer_Copy(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	stream := NewStream(BLAKE2b256{}, nil, nil)
+	writer, err := NewWriter(stream, 64)
+	assert.NoError(t, err)
+	written, err := io.Copy(writer, bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), written)
+	assert.NoError(t, writer.Flush())
+
+	// flushing an empty buffer appends nothing
+	assert.NoError(t, writer.Flush())
+
+	expected := NewStream(BLAKE2b256{}, nil, nil)
+	for offset := 0; offset < len(data); offset += 64 {
+		end := offset + 64
+		if end > len(data) {
+			end = len(data)
+		}
+		assert.NoError(t, expected.Append(data[offset:end]))
+	}
+
+	assert.Equal(t, uint64(157), stream.Blocks())
+	assert.Equal(t, expected.TreeHash(), stream.TreeHash())
+}
+
+func Test_Writer_RetainedBlocks(t *testing.T) {
+	t.Parallel()
+
+	blocks := [][]byte{}
+	writer, err := NewWriter(AppendFunc(func(batch [][]byte) error {
+		blocks = append(blocks, batch...)
+		return nil
+	}), 4)
+	assert.NoError(t, err)
+
+	// later writes must not overwrite the blocks already appended
+	for _, p := range []string{"ab", "cdef", "g", "hijkl", "mn"} {
+		_, err = writer.Write([]byte(p))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, [][]byte{[]byte("abcd"), []byte("efgh"), []byte("ijkl"), []byte("mn")}, blocks)
+}
+
+func Test_Writer_AppendError(t *testing.T) {
+	t.Parallel()
+
+	failure := errors.New("append failed")
+	appended := 0
+	writer, err := NewWriter(AppendFunc(func(blocks [][]byte) error {
+		appended += len(blocks)
+		return failure
+	}), 2)
+	assert.NoError(t, err)
+
+	_, err = writer.Write([]byte("abc"))
+	assert.Equal(t, failure, err)
+
+	// the error is sticky
+	_, err = writer.Write([]byte("d"))
+	assert.Equal(t, failure, err)
+	assert.Equal(t, failure, writer.Flush())
+	assert.Equal(t, 1, appended)
+}
+
+func Test_NewWriter_InvalidBlockSize(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewWriter(NewStream(BLAKE2b256{}, nil, nil), 0)
+	assert.Equal(t, ErrInvalidBlockSize, err)
+}
@@ -0,0 +1,290 @@
+package hypercore
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/indexed"
+	"github.com/kiambogo/go-hypercore/merkle"
+)
+
+var (
+	ErrInvalidProof      = errors.New("proof does not contain the nodes required to reach the roots")
+	ErrInvalidSignature  = errors.New("signature does not match the roots of the proof")
+	ErrUnverifiedProof   = errors.New("proof is verified by a remote tree rather than signed roots")
+	ErrAlgorithmMismatch = errors.New("proof was hashed by a different algorithm than the verifier")
+)
+
+// Proof is a merkle proof of a block, carrying the hash and size of every node required to verify it
+type Proof struct {
+	Index      uint64        // index of the proven block
+	VerifiedBy uint64        // index bounding the roots which verify the block, as passed to flattree.FullRoots
+	Fork       uint64        // fork of the feed when the proof was built; the signature covers only the tree hash
+	Algorithm  string        // name of the algorithm hashing the nodes, as given by merkle.NodeHasher Algorithm
+	Nodes      []merkle.Node // the uncles of the block and the roots required to verify it, excluding the block's own leaf
+	Signature  []byte        // signature of the roots verifying the block; empty if VerifiedBy is 0
+}
+
+// Proof builds the proof of the block at the provided index
+// The digest describes the nodes the remote already holds, as computed by indexed tree Digest;
+// a digest of 0 proves the block all the way to the signed roots of the feed
+func (f *Feed) Proof(index, digest uint64) (Proof, error) {
+	return f.proof(index, func() (indexed.Proof, bool, error) {
+		return f.tree.Proof(index*2, digest, indexed.NewDefaultTree())
+	})
+}
+
+// PeerProof builds the proof of the block at the provided index for a peer, omitting every node the
+// view of the peer shows it to hold, and records the sent nodes in the view
+// The peer verifies the proof with VerifyWith, against the nodes of the proofs it was sent before
+func (f *Feed) PeerProof(view *indexed.PeerView, index uint64) (Proof, error) {
+	return f.proof(index, func() (indexed.Proof, bool, error) {
+		return view.Proof(f.tree, index*2)
+	})
+}
+
+// proof hydrates the tree proof of the block at the provided index with the stored nodes and signature
+func (f *Feed) proof(index uint64, prove func() (indexed.Proof, bool, error)) (Proof, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return Proof{}, ErrClosed
+	}
+
+	treeProof, verified, err := prove()
+	if err != nil {
+		return Proof{}, err
+	}
+	if !verified {
+		return Proof{}, ErrOutOfBounds
+	}
+
+	proof := Proof{
+		Index:      index,
+		VerifiedBy: treeProof.VerifiedBy(),
+		Fork:       f.fork,
+		Algorithm:  f.hasher.Algorithm(),
+		Nodes:      []merkle.Node{},
+	}
+
+	for _, nodeIndex := range treeProof.Nodes() {
+		if nodeIndex == index*2 {
+			continue
+		}
+		node, err := f.node(nodeIndex)
+		if err != nil {
+			return Proof{}, err
+		}
+		proof.Nodes = append(proof.Nodes, node)
+	}
+
+	if proof.VerifiedBy > 0 {
+		if proof.Signature, err = f.signatures.Get(proof.VerifiedBy/2 - 1); err != nil {
+			return Proof{}, err
+		}
+	}
+
+	return proof, nil
+}
+
+// Verify checks that the block is proven by the signed roots of a proof from a BLAKE2b256 feed
+func Verify(proof Proof, block, publicKey []byte) error {
+	return verify(merkle.BLAKE2b256{}, proof, block, publicKey)
+}
+
+func verify(hasher merkle.NodeHasher, proof Proof, block, publicKey []byte) error {
+	if proof.Algorithm != hasher.Algorithm() {
+		return ErrAlgorithmMismatch
+	}
+	if proof.VerifiedBy == 0 {
+		return ErrUnverifiedProof
+	}
+
+	roots, err := verifiedRoots(hasher, proof.VerifiedBy, merkle.NewLeafNode(hasher, proof.Index*2, block), proof.Nodes)
+	if err != nil {
+		return err
+	}
+
+	if !crypto.Verify(publicKey, hasher.HashRoots(roots), proof.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyWith checks that the block is proven by a proof from a BLAKE2b256 feed, given the nodes the
+// verifier already trusts, such as those of earlier proofs it verified
+// Proofs built for a peer view leave out the nodes the peer was already sent, so they are checked
+// against the trusted nodes, rather than the signed roots, when they carry no signature
+func VerifyWith(proof Proof, block, publicKey []byte, trusted []merkle.Node) error {
+	return verifyWith(merkle.BLAKE2b256{}, proof, block, publicKey, trusted)
+}
+
+func verifyWith(hasher merkle.NodeHasher, proof Proof, block, publicKey []byte, trusted []merkle.Node) error {
+	if proof.Algorithm != hasher.Algorithm() {
+		return ErrAlgorithmMismatch
+	}
+
+	leaf := merkle.NewLeafNode(hasher, proof.Index*2, block)
+	if proof.VerifiedBy > 0 {
+		// the trusted nodes stand in for the nodes left out of the proof, taking precedence over its own
+		nodes := append(append([]merkle.Node{}, proof.Nodes...), trusted...)
+		roots, err := verifiedRoots(hasher, proof.VerifiedBy, leaf, nodes)
+		if err != nil {
+			return err
+		}
+		if !crypto.Verify(publicKey, hasher.HashRoots(roots), proof.Signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	return verifyTrusted(hasher, leaf, proof.Nodes, trusted)
+}
+
+// verifyTrusted climbs from the known node through the proof and trusted nodes until reaching
+// a trusted node, which the rebuilt node must match
+func verifyTrusted(hasher merkle.NodeHasher, known merkle.Node, nodes, trusted []merkle.Node) error {
+	trustedByIndex := map[uint64]merkle.Node{}
+	for _, node := range trusted {
+		trustedByIndex[node.Index()] = node
+	}
+	byIndex := map[uint64]merkle.Node{}
+	for _, node := range nodes {
+		byIndex[node.Index()] = node
+	}
+
+	for {
+		if node, ok := trustedByIndex[known.Index()]; ok {
+			if !bytes.Equal(node.Hash(), known.Hash()) {
+				return ErrInvalidProof
+			}
+			return nil
+		}
+
+		siblingIndex := flattree.Sibling(known.Index())
+		sibling, ok := trustedByIndex[siblingIndex]
+		if !ok {
+			if sibling, ok = byIndex[siblingIndex]; !ok {
+				// the proof reaches neither signed roots nor a trusted node
+				return ErrUnverifiedProof
+			}
+		}
+
+		if sibling.Index() < known.Index() {
+			known = merkle.NewParentNode(hasher, sibling, known)
+		} else {
+			known = merkle.NewParentNode(hasher, known, sibling)
+		}
+	}
+}
+
+// verifiedRoots rebuilds the roots bounded by verifiedBy, climbing from the known node
+// through the provided proof nodes
+func verifiedRoots(hasher merkle.NodeHasher, verifiedBy uint64, known merkle.Node, nodes []merkle.Node) ([]merkle.Node, error) {
+	rootIndices, err := flattree.FullRoots(verifiedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	isRoot := map[uint64]bool{}
+	for _, index := range rootIndices {
+		isRoot[index] = true
+	}
+
+	byIndex := map[uint64]merkle.Node{}
+	for _, node := range nodes {
+		byIndex[node.Index()] = node
+	}
+
+	for !isRoot[known.Index()] {
+		if flattree.RightSpan(known.Index()) >= verifiedBy {
+			return nil, ErrInvalidProof
+		}
+
+		sibling, ok := byIndex[flattree.Sibling(known.Index())]
+		if !ok {
+			return nil, ErrInvalidProof
+		}
+
+		if sibling.Index() < known.Index() {
+			known = merkle.NewParentNode(hasher, sibling, known)
+		} else {
+			known = merkle.NewParentNode(hasher, known, sibling)
+		}
+	}
+	byIndex[known.Index()] = known
+
+	roots := []merkle.Node{}
+	for _, index := range rootIndices {
+		root, ok := byIndex[index]
+		if !ok {
+			return nil, ErrInvalidProof
+		}
+		roots = append(roots, root)
+	}
+
+	return roots, nil
+}
+
+// proofNodes returns the minimal set of stored nodes which, alongside the nodes a verifier can
+// compute itself, rebuild the provided roots
+// computable reports if the verifier can compute a node itself, and unknown if the verifier
+// can compute nothing within the subtree of a node
+func (f *Feed) proofNodes(roots []uint64, computable, unknown func(index uint64) bool) ([]merkle.Node, error) {
+	nodes := []merkle.Node{}
+	for _, root := range roots {
+		for _, index := range requiredNodes(root, computable, unknown) {
+			node, err := f.node(index)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// requiredNodes descends from the node at index until reaching nodes the verifier can compute,
+// or subtrees it knows nothing about, returning the latter
+func requiredNodes(index uint64, computable, unknown func(index uint64) bool) []uint64 {
+	if computable(index) {
+		return nil
+	}
+	if unknown(index) {
+		return []uint64{index}
+	}
+
+	left, right, hasChildren := flattree.Children(index)
+	if !hasChildren {
+		return []uint64{index}
+	}
+	return append(requiredNodes(left, computable, unknown), requiredNodes(right, computable, unknown)...)
+}
+
+// rebuildNode returns the known node at index, or hashes it from its rebuilt children
+func rebuildNode(hasher merkle.NodeHasher, index uint64, known map[uint64]merkle.Node) (merkle.Node, error) {
+	if node, ok := known[index]; ok {
+		return node, nil
+	}
+
+	left, right, hasChildren := flattree.Children(index)
+	if !hasChildren {
+		return nil, ErrInvalidProof
+	}
+
+	leftNode, err := rebuildNode(hasher, left, known)
+	if err != nil {
+		return nil, err
+	}
+	rightNode, err := rebuildNode(hasher, right, known)
+	if err != nil {
+		return nil, err
+	}
+
+	return merkle.NewParentNode(hasher, leftNode, rightNode), nil
+}
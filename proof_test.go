@@ -0,0 +1,154 @@
+package hypercore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/indexed"
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_ProofAndVerify(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	for length := uint64(1); length <= 9; length++ {
+		_, err = feed.Append([]byte(fmt.Sprint("block ", length-1)))
+		assert.NoError(t, err)
+
+		for index := uint64(0); index < length; index++ {
+			proof, err := feed.Proof(index, 0)
+			assert.NoError(t, err)
+			assert.Equal(t, index, proof.Index)
+			assert.Equal(t, length*2, proof.VerifiedBy, "block %d of %d", index, length)
+
+			block, err := feed.Get(index)
+			assert.NoError(t, err)
+			assert.NoError(t, Verify(proof, block, feed.PublicKey()), "block %d of %d", index, length)
+		}
+	}
+}
+
+func Test_Feed_ProofNodes(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e"))
+	assert.NoError(t, err)
+
+	proof, err := feed.Proof(0, 0)
+	assert.NoError(t, err)
+
+	indices := []uint64{}
+	for _, node := range proof.Nodes {
+		stored, err := feed.node(node.Index())
+		assert.NoError(t, err)
+		assert.Equal(t, stored, node)
+		indices = append(indices, node.Index())
+	}
+	assert.Equal(t, []uint64{2, 5, 8}, indices)
+
+	signature, err := feed.Signature(4)
+	assert.NoError(t, err)
+	assert.Equal(t, signature, proof.Signature)
+
+	_, err = feed.Proof(5, 0)
+	assert.Equal(t, ErrOutOfBounds, err)
+}
+
+func Test_Verify_Invalid(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e"))
+	assert.NoError(t, err)
+
+	proof, err := feed.Proof(2, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(proof, []byte("c"), feed.PublicKey()))
+
+	assert.Equal(t, ErrInvalidSignature, Verify(proof, []byte("x"), feed.PublicKey()))
+
+	other, err := crypto.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.Equal(t, ErrInvalidSignature, Verify(proof, []byte("c"), other.PublicKey))
+
+	missing := proof
+	missing.Nodes = proof.Nodes[1:]
+	assert.Equal(t, ErrInvalidProof, Verify(missing, []byte("c"), feed.PublicKey()))
+
+	moved := proof
+	moved.Index = 3
+	assert.Error(t, Verify(moved, []byte("c"), feed.PublicKey()))
+
+	// a digest of 1 means the remote already holds a verified copy of the block
+	proof, err = feed.Proof(2, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, proof.Nodes)
+	assert.Equal(t, ErrUnverifiedProof, Verify(proof, []byte("c"), feed.PublicKey()))
+}
+
+func Test_VerifyWith_SignedProof(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e"))
+	assert.NoError(t, err)
+
+	proof, err := feed.Proof(2, 0)
+	assert.NoError(t, err)
+	trusted := proof.Nodes[:1]
+
+	// a trusted node may be left out of a signed proof
+	omitted := proof
+	omitted.Nodes = proof.Nodes[1:]
+	assert.Equal(t, ErrInvalidProof, Verify(omitted, []byte("c"), feed.PublicKey()))
+	assert.NoError(t, VerifyWith(omitted, []byte("c"), feed.PublicKey(), trusted))
+	assert.Equal(t, ErrInvalidSignature, VerifyWith(omitted, []byte("x"), feed.PublicKey(), trusted))
+
+	// but must match the roots the feed signed
+	forged := []merkle.Node{merkle.NewNode(trusted[0].Index(), make([]byte, merkle.HashSize), trusted[0].Size())}
+	assert.Equal(t, ErrInvalidSignature, VerifyWith(omitted, []byte("c"), feed.PublicKey(), forged))
+}
+
+func Test_Feed_PeerProof(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint("block ", i)))
+		assert.NoError(t, err)
+	}
+
+	view := indexed.NewPeerView()
+	first, err := feed.PeerProof(view, 0)
+	assert.NoError(t, err)
+	assert.Len(t, first.Nodes, 3)
+	assert.NoError(t, Verify(first, []byte("block 0"), feed.PublicKey()))
+	assert.NoError(t, VerifyWith(first, []byte("block 0"), feed.PublicKey(), nil))
+
+	// later proofs to the same peer leave out the nodes it was already sent
+	proof, err := feed.PeerProof(view, 2)
+	assert.NoError(t, err)
+	assert.Len(t, proof.Nodes, 1)
+	assert.Equal(t, uint64(6), proof.Nodes[0].Index())
+	assert.Equal(t, uint64(0), proof.VerifiedBy)
+	assert.Empty(t, proof.Signature)
+
+	// so they are verified against the nodes of the earlier proof, which the peer already trusts
+	assert.Equal(t, ErrUnverifiedProof, Verify(proof, []byte("block 2"), feed.PublicKey()))
+	assert.NoError(t, VerifyWith(proof, []byte("block 2"), feed.PublicKey(), first.Nodes))
+	assert.Equal(t, ErrInvalidProof, VerifyWith(proof, []byte("block x"), feed.PublicKey(), first.Nodes))
+	assert.Equal(t, ErrUnverifiedProof, VerifyWith(proof, []byte("block 2"), feed.PublicKey(), nil))
+
+	_, err = feed.PeerProof(view, 8)
+	assert.Equal(t, ErrOutOfBounds, err)
+}
@@ -0,0 +1,112 @@
+package hypercore
+
+import (
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/merkle"
+)
+
+// RangeProof is a merkle proof of the contiguous blocks in the range [Start, End)
+type RangeProof struct {
+	Start     uint64        // index of the first proven block
+	End       uint64        // index after the last proven block
+	Length    uint64        // length of the signed tree verifying the range
+	Fork      uint64        // fork of the feed when the proof was built
+	Algorithm string        // name of the algorithm hashing the nodes, as given by merkle.NodeHasher Algorithm
+	Nodes     []merkle.Node // the minimal set of nodes which, with the blocks, rebuild the roots
+	Signature []byte        // signature of the verifying tree
+}
+
+// RangeProof builds the proof of the blocks in the range [start, end), against the current roots of the feed
+func (f *Feed) RangeProof(start, end uint64) (RangeProof, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return RangeProof{}, ErrClosed
+	}
+	length := f.stream.Blocks()
+	if start >= end || end > length {
+		return RangeProof{}, ErrOutOfBounds
+	}
+
+	roots, err := flattree.FullRoots(length * 2)
+	if err != nil {
+		return RangeProof{}, err
+	}
+
+	proof := RangeProof{Start: start, End: end, Length: length, Fork: f.fork, Algorithm: f.hasher.Algorithm()}
+	computable, unknown := rangeSpans(start, end)
+	if proof.Nodes, err = f.proofNodes(roots, computable, unknown); err != nil {
+		return RangeProof{}, err
+	}
+
+	if proof.Signature, err = f.signatures.Get(length - 1); err != nil {
+		return RangeProof{}, err
+	}
+
+	return proof, nil
+}
+
+// rangeSpans returns checks for whether a node's subtree lies wholly within, or wholly outside,
+// the blocks in the range [start, end)
+func rangeSpans(start, end uint64) (within, outside func(index uint64) bool) {
+	within = func(index uint64) bool {
+		left, right := flattree.Spans(index)
+		return left >= start*2 && right < end*2
+	}
+	outside = func(index uint64) bool {
+		left, right := flattree.Spans(index)
+		return right < start*2 || left >= end*2
+	}
+	return
+}
+
+// VerifyRange checks that the blocks are proven by the signed roots of a range proof from a BLAKE2b256 feed
+func VerifyRange(proof RangeProof, blocks [][]byte, publicKey []byte) error {
+	return verifyRange(merkle.BLAKE2b256{}, proof, blocks, publicKey)
+}
+
+func verifyRange(hasher merkle.NodeHasher, proof RangeProof, blocks [][]byte, publicKey []byte) error {
+	if proof.Algorithm != hasher.Algorithm() {
+		return ErrAlgorithmMismatch
+	}
+	if proof.Start >= proof.End || proof.End > proof.Length || uint64(len(blocks)) != proof.End-proof.Start {
+		return ErrInvalidProof
+	}
+
+	known := map[uint64]merkle.Node{}
+	for i, block := range blocks {
+		index := (proof.Start + uint64(i)) * 2
+		known[index] = merkle.NewLeafNode(hasher, index, block)
+	}
+
+	// proof nodes must lie wholly outside the range, so the blocks cannot be bypassed
+	_, outside := rangeSpans(proof.Start, proof.End)
+	for _, node := range proof.Nodes {
+		if !outside(node.Index()) {
+			return ErrInvalidProof
+		}
+		known[node.Index()] = node
+	}
+
+	rootIndices, err := flattree.FullRoots(proof.Length * 2)
+	if err != nil {
+		return err
+	}
+
+	roots := []merkle.Node{}
+	for _, index := range rootIndices {
+		root, err := rebuildNode(hasher, index, known)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, root)
+	}
+
+	if !crypto.Verify(publicKey, hasher.HashRoots(roots), proof.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package hypercore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_RangeProof(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	blocks := [][]byte{}
+	for i := 0; i < 11; i++ {
+		blocks = append(blocks, []byte(fmt.Sprint("block ", i)))
+	}
+	_, err = feed.Append(blocks...)
+	assert.NoError(t, err)
+
+	for start := 0; start < len(blocks); start++ {
+		for end := start + 1; end <= len(blocks); end++ {
+			proof, err := feed.RangeProof(uint64(start), uint64(end))
+			assert.NoError(t, err)
+			assert.NoError(t, VerifyRange(proof, blocks[start:end], feed.PublicKey()), "range [%d, %d)", start, end)
+		}
+	}
+}
+
+func Test_Feed_RangeProofNodes(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint(i)))
+		assert.NoError(t, err)
+	}
+
+	indices := func(proof RangeProof) []uint64 {
+		result := []uint64{}
+		for _, node := range proof.Nodes {
+			result = append(result, node.Index())
+		}
+		return result
+	}
+
+	// blocks 2 through 5 are rebuilt from the leaves, needing only their outer uncles
+	proof, err := feed.RangeProof(2, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 13}, indices(proof))
+
+	// the whole tree needs no nodes at all
+	proof, err = feed.RangeProof(0, 8)
+	assert.NoError(t, err)
+	assert.Empty(t, proof.Nodes)
+
+	// a single block needs the same nodes as a single block proof
+	proof, err = feed.RangeProof(5, 6)
+	assert.NoError(t, err)
+	single, err := feed.Proof(5, 0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, indices(proof), indices(RangeProof{Nodes: single.Nodes}))
+
+	_, err = feed.RangeProof(3, 3)
+	assert.Equal(t, ErrOutOfBounds, err)
+	_, err = feed.RangeProof(3, 9)
+	assert.Equal(t, ErrOutOfBounds, err)
+}
+
+func Test_VerifyRange_Invalid(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	blocks := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e")}
+	_, err = feed.Append(blocks...)
+	assert.NoError(t, err)
+
+	proof, err := feed.RangeProof(1, 4)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyRange(proof, blocks[1:4], feed.PublicKey()))
+
+	assert.Equal(t, ErrInvalidProof, VerifyRange(proof, blocks[1:3], feed.PublicKey()))
+	assert.Equal(t, ErrInvalidSignature, VerifyRange(proof, [][]byte{[]byte("b"), []byte("x"), []byte("d")}, feed.PublicKey()))
+
+	missing := proof
+	missing.Nodes = proof.Nodes[1:]
+	assert.Equal(t, ErrInvalidProof, VerifyRange(missing, blocks[1:4], feed.PublicKey()))
+
+	// substituting the roots for the proof nodes must not bypass the blocks
+	substituted := proof
+	substituted.Nodes = feed.Roots()
+	assert.Equal(t, ErrInvalidProof, VerifyRange(substituted, [][]byte{[]byte("x"), []byte("y"), []byte("z")}, feed.PublicKey()))
+}
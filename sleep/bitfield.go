@@ -0,0 +1,102 @@
+package sleep
+
+import (
+	"math/bits"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+const (
+	// DataPageSize is the number of bytes of the data bitfield within a page
+	DataPageSize = 1024
+	// TreePageSize is the number of bytes of the tree bitfield within a page
+	TreePageSize = 2048
+	// IndexPageSize is the number of bytes of the index bitfield within a page
+	IndexPageSize = 256
+	// BitfieldPageSize is the size of a page of the bitfield file
+	BitfieldPageSize = DataPageSize + TreePageSize + IndexPageSize
+)
+
+// Bitfield is the SLEEP file holding the data, tree and index bitfields of a feed
+// Each entry is a page made up of a chunk of each bitfield, in that order
+type Bitfield struct {
+	file
+}
+
+// OpenBitfield opens the bitfield file within the provided storage
+func OpenBitfield(s storage.Storage) (*Bitfield, error) {
+	f, err := openFile(s, NewBitfieldHeader())
+	if err != nil {
+		return nil, err
+	}
+	return &Bitfield{file: f}, nil
+}
+
+// GetPage reads the page at the provided index into the data, tree and index bitfields
+func (b Bitfield) GetPage(page uint64, data, tree, index *bitfield.Bitfield) error {
+	buf, err := b.get(page)
+	if err != nil {
+		return err
+	}
+	DecodeBitfieldPage(page, buf, data, tree, index)
+	return nil
+}
+
+// PutPage writes the page at the provided index from the data, tree and index bitfields
+func (b Bitfield) PutPage(page uint64, data, tree, index *bitfield.Bitfield) error {
+	return b.put(page, EncodeBitfieldPage(page, data, tree, index))
+}
+
+// Load reads every page of the file into the data, tree and index bitfields
+func (b Bitfield) Load(data, tree, index *bitfield.Bitfield) error {
+	pages, err := b.Len()
+	if err != nil {
+		return err
+	}
+
+	for page := uint64(0); page < pages; page++ {
+		if err := b.GetPage(page, data, tree, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EncodeBitfieldPage serializes the page at the provided index from the data, tree and index bitfields
+func EncodeBitfieldPage(page uint64, data, tree, index *bitfield.Bitfield) []byte {
+	buf := make([]byte, BitfieldPageSize)
+	encodeChunk(buf[:DataPageSize], page, data)
+	encodeChunk(buf[DataPageSize:DataPageSize+TreePageSize], page, tree)
+	encodeChunk(buf[DataPageSize+TreePageSize:], page, index)
+
+	return buf
+}
+
+// DecodeBitfieldPage parses the page at the provided index into the data, tree and index bitfields
+func DecodeBitfieldPage(page uint64, buf []byte, data, tree, index *bitfield.Bitfield) {
+	decodeChunk(buf[:DataPageSize], page, data)
+	decodeChunk(buf[DataPageSize:DataPageSize+TreePageSize], page, tree)
+	decodeChunk(buf[DataPageSize+TreePageSize:BitfieldPageSize], page, index)
+}
+
+// SLEEP bitfields store the first bit of each byte in the most significant position,
+// whereas the in-memory bitfield stores it in the least significant position
+func encodeChunk(chunk []byte, page uint64, bf *bitfield.Bitfield) {
+	offset := page * uint64(len(chunk))
+	for i := range chunk {
+		chunk[i] = bits.Reverse8(bf.GetByte(offset + uint64(i)))
+	}
+}
+
+func decodeChunk(chunk []byte, page uint64, bf *bitfield.Bitfield) {
+	offset := page * uint64(len(chunk))
+	for i, b := range chunk {
+		// skip blank bytes to avoid allocating pages for sparse bitfields
+		if b == 0 && bf.GetByte(offset+uint64(i)) == 0 {
+			continue
+		}
+		bf.SetByte(offset+uint64(i), bits.Reverse8(b))
+	}
+}
@@ -0,0 +1,68 @@
+package sleep
+
+import (
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/bitfield"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncodeBitfieldPage(t *testing.T) {
+	t.Parallel()
+
+	data := bitfield.NewBitfield(0)
+	tree := bitfield.NewBitfield(0)
+	index := bitfield.NewBitfield(0)
+
+	data.SetBit(0, true)
+	data.SetBit(9, true)
+	tree.SetBit(2, true)
+	index.SetBit(7, true)
+
+	buf := EncodeBitfieldPage(0, data, tree, index)
+	assert.Len(t, buf, BitfieldPageSize)
+
+	// the first bit of every byte is stored in the most significant position
+	assert.Equal(t, byte(0x80), buf[0])
+	assert.Equal(t, byte(0x40), buf[1])
+	assert.Equal(t, byte(0x20), buf[DataPageSize])
+	assert.Equal(t, byte(0x01), buf[DataPageSize+TreePageSize])
+}
+
+func Test_Bitfield_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data := bitfield.NewBitfield(0)
+	tree := bitfield.NewBitfield(0)
+	index := bitfield.NewBitfield(0)
+
+	dataBits := []int{0, 5, 8191, 8192, 9000}
+	treeBits := []int{0, 1, 2, 16384, 20000}
+	for _, bit := range dataBits {
+		data.SetBit(bit, true)
+	}
+	for _, bit := range treeBits {
+		tree.SetBit(bit, true)
+	}
+
+	file, err := OpenBitfield(storage.NewMemory(0))
+	assert.NoError(t, err)
+	assert.NoError(t, file.PutPage(0, data, tree, index))
+	assert.NoError(t, file.PutPage(1, data, tree, index))
+
+	loadedData := bitfield.NewBitfield(0)
+	loadedTree := bitfield.NewBitfield(0)
+	loadedIndex := bitfield.NewBitfield(0)
+	assert.NoError(t, file.Load(loadedData, loadedTree, loadedIndex))
+
+	for _, bit := range dataBits {
+		assert.True(t, loadedData.GetBit(uint64(bit)), "data bit %d", bit)
+	}
+	for _, bit := range treeBits {
+		assert.True(t, loadedTree.GetBit(uint64(bit)), "tree bit %d", bit)
+	}
+	assert.False(t, loadedData.GetBit(1))
+	assert.False(t, loadedTree.GetBit(3))
+	assert.True(t, loadedIndex.IsEmpty())
+}
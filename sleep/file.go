@@ -0,0 +1,91 @@
+package sleep
+
+import (
+	"fmt"
+
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+// file is a SLEEP file: a header followed by a sequence of fixed size entries
+type file struct {
+	storage storage.Storage
+	header  Header
+}
+
+// openFile validates the header of an existing SLEEP file, or writes the header to an empty one
+func openFile(s storage.Storage, header Header) (file, error) {
+	f := file{storage: s, header: header}
+
+	stat, err := s.Stat()
+	if err != nil {
+		return f, err
+	}
+
+	if stat.Size == 0 {
+		buf, err := header.Encode()
+		if err != nil {
+			return f, err
+		}
+		return f, s.Write(0, buf)
+	}
+
+	buf, err := s.Read(0, HeaderSize)
+	if err != nil {
+		return f, err
+	}
+	existing, err := DecodeHeader(buf)
+	if err != nil {
+		return f, err
+	}
+	if existing != header {
+		return f, fmt.Errorf("%w: expected %+v, found %+v", ErrHeaderMismatch, header, existing)
+	}
+
+	return f, nil
+}
+
+// Header returns the header of the file
+func (f file) Header() Header {
+	return f.header
+}
+
+// Len returns the number of entries within the file, including any blank entries
+func (f file) Len() (uint64, error) {
+	stat, err := f.storage.Stat()
+	if err != nil || stat.Size < HeaderSize {
+		return 0, err
+	}
+
+	entrySize := uint64(f.header.EntrySize)
+	return (stat.Size - HeaderSize + entrySize - 1) / entrySize, nil
+}
+
+// Del blanks the entry at the provided index
+func (f file) Del(index uint64) error {
+	return f.storage.Del(f.offset(index), uint64(f.header.EntrySize))
+}
+
+// Truncate discards all entries at or after the provided index
+func (f file) Truncate(index uint64) error {
+	return f.storage.Truncate(f.offset(index))
+}
+
+// Close closes the underlying storage
+func (f file) Close() error {
+	return f.storage.Close()
+}
+
+func (f file) get(index uint64) ([]byte, error) {
+	return f.storage.Read(f.offset(index), uint64(f.header.EntrySize))
+}
+
+func (f file) put(index uint64, entry []byte) error {
+	if len(entry) != int(f.header.EntrySize) {
+		return fmt.Errorf("entry of %d bytes does not match the entry size of %d", len(entry), f.header.EntrySize)
+	}
+	return f.storage.Write(f.offset(index), entry)
+}
+
+func (f file) offset(index uint64) uint64 {
+	return HeaderSize + index*uint64(f.header.EntrySize)
+}
@@ -0,0 +1,94 @@
+package sleep
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// HeaderSize is the size of the header at the start of every SLEEP file, in bytes
+const HeaderSize = 32
+
+const maxAlgorithmLength = HeaderSize - 8
+
+var (
+	ErrInvalidHeader  = errors.New("invalid SLEEP header")
+	ErrHeaderMismatch = errors.New("SLEEP header does not match the expected file type or algorithm")
+)
+
+// magic is the prefix of the 4 byte magic number, which is completed by the file type
+var magic = [3]byte{0x05, 0x02, 0x57}
+
+// FileType identifies the kind of SLEEP file, and forms the last byte of its magic number
+type FileType byte
+
+const (
+	BitfieldFile FileType = iota
+	SignaturesFile
+	TreeFile
+)
+
+// Header describes the layout of the fixed size entries within a SLEEP file
+type Header struct {
+	Type      FileType
+	Version   uint8
+	EntrySize uint16
+	Algorithm string
+}
+
+// NewBitfieldHeader returns the header of a bitfield file
+func NewBitfieldHeader() Header {
+	return Header{Type: BitfieldFile, EntrySize: BitfieldPageSize}
+}
+
+// NewSignaturesHeader returns the header of an Ed25519 signatures file
+func NewSignaturesHeader() Header {
+	return Header{Type: SignaturesFile, EntrySize: SignatureSize, Algorithm: "Ed25519"}
+}
+
+// NewTreeHeader returns the header of a tree file hashed by the named algorithm,
+// as given by merkle.NodeHasher Algorithm
+func NewTreeHeader(algorithm string) Header {
+	return Header{Type: TreeFile, EntrySize: TreeEntrySize, Algorithm: algorithm}
+}
+
+// Encode serializes the header into its 32 byte representation
+//
+//	4 bytes magic number | 1 byte version | 2 bytes entry size (big endian) |
+//	1 byte algorithm name length | algorithm name | zero padding
+func (h Header) Encode() ([]byte, error) {
+	if len(h.Algorithm) > maxAlgorithmLength {
+		return nil, errors.New("algorithm name must not exceed 24 bytes")
+	}
+
+	buf := make([]byte, HeaderSize)
+	copy(buf, magic[:])
+	buf[3] = byte(h.Type)
+	buf[4] = h.Version
+	binary.BigEndian.PutUint16(buf[5:7], h.EntrySize)
+	buf[7] = byte(len(h.Algorithm))
+	copy(buf[8:], h.Algorithm)
+
+	return buf, nil
+}
+
+// DecodeHeader parses a header from the start of a SLEEP file
+func DecodeHeader(buf []byte) (Header, error) {
+	if len(buf) < HeaderSize {
+		return Header{}, ErrInvalidHeader
+	}
+	if buf[0] != magic[0] || buf[1] != magic[1] || buf[2] != magic[2] || buf[3] > byte(TreeFile) {
+		return Header{}, ErrInvalidHeader
+	}
+
+	algorithmLength := int(buf[7])
+	if algorithmLength > maxAlgorithmLength {
+		return Header{}, ErrInvalidHeader
+	}
+
+	return Header{
+		Type:      FileType(buf[3]),
+		Version:   buf[4],
+		EntrySize: binary.BigEndian.Uint16(buf[5:7]),
+		Algorithm: string(buf[8 : 8+algorithmLength]),
+	}, nil
+}
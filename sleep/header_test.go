@@ -0,0 +1,68 @@
+package sleep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Header_EncodeTree(t *testing.T) {
+	t.Parallel()
+
+	// header of a tree file as written by hypercore v9
+	expected := append([]byte{
+		0x05, 0x02, 0x57, 0x02, // magic number
+		0x00,       // version
+		0x00, 0x28, // entry size
+		0x07, // algorithm name length
+	}, []byte("BLAKE2b")...)
+	expected = append(expected, make([]byte, 17)...)
+
+	buf, err := NewTreeHeader("BLAKE2b").Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, buf)
+
+	header, err := DecodeHeader(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, NewTreeHeader("BLAKE2b"), header)
+}
+
+func Test_Header_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, header := range []Header{NewBitfieldHeader(), NewSignaturesHeader(), NewTreeHeader("BLAKE2b")} {
+		buf, err := header.Encode()
+		assert.NoError(t, err)
+		assert.Len(t, buf, HeaderSize)
+
+		decoded, err := DecodeHeader(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, header, decoded)
+	}
+}
+
+func Test_Header_AlgorithmTooLong(t *testing.T) {
+	t.Parallel()
+
+	_, err := Header{Type: TreeFile, EntrySize: 40, Algorithm: "an-algorithm-name-of-25-b"}.Encode()
+	assert.Error(t, err)
+}
+
+func Test_DecodeHeader_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := DecodeHeader([]byte{0x05, 0x02, 0x57})
+	assert.Equal(t, ErrInvalidHeader, err)
+
+	buf, err := NewTreeHeader("BLAKE2b").Encode()
+	assert.NoError(t, err)
+
+	buf[0] = 0x06
+	_, err = DecodeHeader(buf)
+	assert.Equal(t, ErrInvalidHeader, err)
+
+	buf[0] = 0x05
+	buf[3] = 0x03
+	_, err = DecodeHeader(buf)
+	assert.Equal(t, ErrInvalidHeader, err)
+}
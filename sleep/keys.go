@@ -0,0 +1,47 @@
+package sleep
+
+import (
+	"errors"
+
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+const (
+	// PublicKeySize is the size of the key file, holding an Ed25519 public key
+	PublicKeySize = 32
+	// SecretKeySize is the size of the secret_key file, holding an Ed25519 secret key
+	SecretKeySize = 64
+)
+
+var ErrInvalidKeySize = errors.New("key does not match the expected key size")
+
+// ReadPublicKey reads the public key from the key file
+func ReadPublicKey(s storage.Storage) ([]byte, error) {
+	return s.Read(0, PublicKeySize)
+}
+
+// WritePublicKey writes the public key to the key file
+func WritePublicKey(s storage.Storage, key []byte) error {
+	return writeKey(s, key, PublicKeySize)
+}
+
+// ReadSecretKey reads the secret key from the secret_key file
+func ReadSecretKey(s storage.Storage) ([]byte, error) {
+	return s.Read(0, SecretKeySize)
+}
+
+// WriteSecretKey writes the secret key to the secret_key file
+func WriteSecretKey(s storage.Storage, key []byte) error {
+	return writeKey(s, key, SecretKeySize)
+}
+
+// Key files are raw keys, without a SLEEP header
+func writeKey(s storage.Storage, key []byte, size int) error {
+	if len(key) != size {
+		return ErrInvalidKeySize
+	}
+	if err := s.Truncate(0); err != nil {
+		return err
+	}
+	return s.Write(0, key)
+}
@@ -0,0 +1,34 @@
+package sleep
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Keys(t *testing.T) {
+	t.Parallel()
+
+	public := bytes.Repeat([]byte{1}, PublicKeySize)
+	secret := bytes.Repeat([]byte{2}, SecretKeySize)
+
+	publicStorage := storage.NewMemory(0)
+	secretStorage := storage.NewMemory(0)
+
+	assert.NoError(t, WritePublicKey(publicStorage, public))
+	assert.NoError(t, WriteSecretKey(secretStorage, secret))
+	assert.Equal(t, ErrInvalidKeySize, WritePublicKey(publicStorage, secret))
+
+	key, err := ReadPublicKey(publicStorage)
+	assert.NoError(t, err)
+	assert.Equal(t, public, key)
+
+	key, err = ReadSecretKey(secretStorage)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, key)
+
+	_, err = ReadSecretKey(storage.NewMemory(0))
+	assert.Equal(t, storage.ErrOutOfBounds, err)
+}
@@ -0,0 +1,33 @@
+package sleep
+
+import (
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+// SignatureSize is the size of an Ed25519 signature, in bytes
+const SignatureSize = 64
+
+// Signatures is the SLEEP file holding the signature of the merkle roots after each append
+// The signature at index i signs the roots of the tree with a length of i+1 blocks
+type Signatures struct {
+	file
+}
+
+// OpenSignatures opens the signatures file within the provided storage
+func OpenSignatures(s storage.Storage) (*Signatures, error) {
+	f, err := openFile(s, NewSignaturesHeader())
+	if err != nil {
+		return nil, err
+	}
+	return &Signatures{file: f}, nil
+}
+
+// Get returns the signature at the provided index
+func (s Signatures) Get(index uint64) ([]byte, error) {
+	return s.get(index)
+}
+
+// Put stores the signature at the provided index
+func (s Signatures) Put(index uint64, signature []byte) error {
+	return s.put(index, signature)
+}
@@ -0,0 +1,28 @@
+package sleep
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Signatures(t *testing.T) {
+	t.Parallel()
+
+	signatures, err := OpenSignatures(storage.NewMemory(0))
+	assert.NoError(t, err)
+	assert.Equal(t, NewSignaturesHeader(), signatures.Header())
+
+	sig := bytes.Repeat([]byte{7}, SignatureSize)
+	assert.NoError(t, signatures.Put(0, sig))
+	assert.Error(t, signatures.Put(1, sig[:32]))
+
+	got, err := signatures.Get(0)
+	assert.NoError(t, err)
+	assert.Equal(t, sig, got)
+
+	_, err = signatures.Get(1)
+	assert.Equal(t, storage.ErrOutOfBounds, err)
+}
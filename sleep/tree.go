@@ -0,0 +1,106 @@
+package sleep
+
+import (
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+const (
+	// TreeHashSize is the size of a node hash within the tree file, in bytes
+	TreeHashSize = merkle.HashSize
+	// TreeEntrySize is the size of a node within the tree file: a hash followed by a uint64 size
+	TreeEntrySize = merkle.EncodedNodeSize
+)
+
+var ErrInvalidHashSize = merkle.ErrInvalidHashSize
+
+// TreeEntry is a merkle tree node as stored in the tree file
+// The flat tree index of the node is implied by its position within the file
+type TreeEntry struct {
+	Hash []byte
+	Size uint64 // the number of bytes spanned by the node
+}
+
+// NewTreeEntry constructs the tree entry of a node
+func NewTreeEntry(node merkle.Node) TreeEntry {
+	return TreeEntry{Hash: node.Hash(), Size: node.Size()}
+}
+
+// Node constructs the merkle node of the entry, located at the provided flat tree index
+func (e TreeEntry) Node(index uint64) merkle.Node {
+	return merkle.NewNode(index, e.Hash, e.Size)
+}
+
+// IsBlank checks if the entry is an unwritten, all zero entry
+func (e TreeEntry) IsBlank() bool {
+	if e.Size != 0 {
+		return false
+	}
+	for _, b := range e.Hash {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode serializes the entry into its 40 byte representation, as given by merkle.DefaultNode MarshalBinary
+func (e TreeEntry) Encode() ([]byte, error) {
+	return merkle.NewNode(0, e.Hash, e.Size).MarshalBinary()
+}
+
+// DecodeTreeEntry parses a 40 byte tree entry
+func DecodeTreeEntry(buf []byte) (TreeEntry, error) {
+	var node merkle.DefaultNode
+	if err := node.UnmarshalBinary(buf); err != nil {
+		return TreeEntry{}, err
+	}
+	return NewTreeEntry(node), nil
+}
+
+// Tree is the SLEEP file holding the hash and size of every merkle tree node, by flat tree index
+type Tree struct {
+	file
+}
+
+// OpenTree opens the tree file within the provided storage, holding nodes hashed by the named algorithm
+// Opening a tree file built with a different algorithm fails with ErrHeaderMismatch
+func OpenTree(s storage.Storage, algorithm string) (*Tree, error) {
+	f, err := openFile(s, NewTreeHeader(algorithm))
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{file: f}, nil
+}
+
+// Get returns the entry of the node at the provided flat tree index
+func (t Tree) Get(index uint64) (TreeEntry, error) {
+	buf, err := t.get(index)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	return DecodeTreeEntry(buf)
+}
+
+// Put stores the entry of the node at the provided flat tree index
+func (t Tree) Put(index uint64, entry TreeEntry) error {
+	buf, err := entry.Encode()
+	if err != nil {
+		return err
+	}
+	return t.put(index, buf)
+}
+
+// PutBatch stores the entries of consecutive nodes, starting at the provided flat tree index, in a single write
+func (t Tree) PutBatch(index uint64, entries []TreeEntry) error {
+	nodes := make([]merkle.Node, 0, len(entries))
+	for i, entry := range entries {
+		nodes = append(nodes, entry.Node(index+uint64(i)))
+	}
+
+	buf, err := merkle.MarshalNodes(nodes)
+	if err != nil {
+		return err
+	}
+	return t.storage.Write(t.offset(index), buf)
+}
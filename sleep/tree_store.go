@@ -0,0 +1,127 @@
+package sleep
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+// TreeStore is a merkle.NodeStore backed by a tree file
+// Nodes are buffered in memory when put, and written to the file in runs of consecutive entries when flushed
+type TreeStore struct {
+	tree    *Tree
+	pending map[uint64]TreeEntry
+	mu      *sync.RWMutex
+}
+
+func NewTreeStore(tree *Tree) *TreeStore {
+	return &TreeStore{
+		tree:    tree,
+		pending: map[uint64]TreeEntry{},
+		mu:      &sync.RWMutex{},
+	}
+}
+
+// Get returns the node at the provided flat tree index, whether flushed or not
+func (s *TreeStore) Get(index uint64) (merkle.Node, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if entry, ok := s.pending[index]; ok {
+		return entry.Node(index), nil
+	}
+
+	entry, err := s.tree.Get(index)
+	if errors.Is(err, storage.ErrOutOfBounds) || (err == nil && entry.IsBlank()) {
+		return nil, merkle.ErrMissingNode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Node(index), nil
+}
+
+// Put buffers the node until the next flush
+func (s *TreeStore) Put(node merkle.Node) error {
+	entry := NewTreeEntry(node)
+	if len(entry.Hash) != TreeHashSize {
+		return ErrInvalidHashSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[node.Index()] = entry
+	return nil
+}
+
+// Flush writes every buffered node to the tree file
+func (s *TreeStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indices := make([]uint64, 0, len(s.pending))
+	for index := range s.pending {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	for start := 0; start < len(indices); {
+		end := start + 1
+		for end < len(indices) && indices[end] == indices[end-1]+1 {
+			end++
+		}
+
+		entries := make([]TreeEntry, 0, end-start)
+		for _, index := range indices[start:end] {
+			entries = append(entries, s.pending[index])
+		}
+		if err := s.tree.PutBatch(indices[start], entries); err != nil {
+			return err
+		}
+		for _, index := range indices[start:end] {
+			delete(s.pending, index)
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+// Truncate drops every node spanning a block at or after length, whether flushed or not
+func (s *TreeStore) Truncate(length uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.pending {
+		if flattree.RightSpan(index) >= length*2 {
+			delete(s.pending, index)
+		}
+	}
+
+	if length == 0 {
+		return s.tree.Truncate(0)
+	}
+
+	// every node after the last remaining leaf spans a removed block
+	last := length*2 - 2
+	if err := s.tree.Truncate(last + 1); err != nil {
+		return err
+	}
+
+	// as do the ancestors of the first removed leaf which precede the last remaining leaf
+	for parent := flattree.Parent(last + 2); flattree.Index(flattree.Depth(parent), 0) < last; parent = flattree.Parent(parent) {
+		if parent < last {
+			if err := s.tree.Del(parent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
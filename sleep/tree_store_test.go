@@ -0,0 +1,120 @@
+package sleep
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TreeStore(t *testing.T) {
+	t.Parallel()
+
+	mem := storage.NewMemory(0)
+	tree, err := OpenTree(mem, "BLAKE2b")
+	assert.NoError(t, err)
+	store := NewTreeStore(tree)
+
+	_, err = store.Get(0)
+	assert.Equal(t, merkle.ErrMissingNode, err)
+
+	hasher := merkle.BLAKE2b256{}
+	left := merkle.NewLeafNode(hasher, 0, []byte("a"))
+	right := merkle.NewLeafNode(hasher, 2, []byte("bc"))
+	assert.NoError(t, store.Put(left))
+	assert.NoError(t, store.Put(right))
+	assert.NoError(t, store.Put(merkle.NewParentNode(hasher, left, right)))
+
+	// buffered nodes are readable before they are flushed
+	length, err := tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), length)
+	node, err := store.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, right.Hash(), node.Hash())
+
+	assert.NoError(t, store.Flush())
+	length, err = tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), length)
+
+	entry, err := tree.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), entry.Size)
+
+	node, err = store.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entry.Node(1), node)
+
+	// blank entries are missing nodes
+	assert.NoError(t, store.Put(merkle.NewLeafNode(hasher, 6, []byte("ghij"))))
+	assert.NoError(t, store.Flush())
+	_, err = store.Get(4)
+	assert.Equal(t, merkle.ErrMissingNode, err)
+
+	assert.Equal(t, ErrInvalidHashSize, store.Put(merkle.NewLeafNode(merkle.BLAKE2b512{}, 8, []byte("d"))))
+}
+
+func Test_TreeStore_Stream(t *testing.T) {
+	t.Parallel()
+
+	tree, err := OpenTree(storage.NewMemory(0), "BLAKE2b")
+	assert.NoError(t, err)
+
+	stream := merkle.NewStream(merkle.BLAKE2b256{}, nil, NewTreeStore(tree))
+	expected := merkle.NewStream(merkle.BLAKE2b256{}, nil, nil)
+	for i := 0; i < 9; i++ {
+		assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+		assert.NoError(t, expected.Append([]byte(fmt.Sprint(i))))
+	}
+
+	// node 15 is not complete until a tenth block is appended
+	for index := uint64(0); index < 17; index++ {
+		entry, err := tree.Get(index)
+		assert.NoError(t, err)
+		node, err := expected.Store().Get(index)
+		if index == 15 {
+			assert.Equal(t, merkle.ErrMissingNode, err)
+			assert.True(t, entry.IsBlank())
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, NewTreeEntry(node), entry, "node %d", index)
+	}
+}
+
+func Test_TreeStore_Truncate(t *testing.T) {
+	t.Parallel()
+
+	tree, err := OpenTree(storage.NewMemory(0), "BLAKE2b")
+	assert.NoError(t, err)
+	store := NewTreeStore(tree)
+
+	stream := merkle.NewStream(merkle.BLAKE2b256{}, nil, store)
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, stream.Append([]byte(fmt.Sprint(i))))
+	}
+	// an unflushed node spanning a dropped block is discarded too
+	assert.NoError(t, store.Put(merkle.NewLeafNode(merkle.BLAKE2b256{}, 14, []byte("7"))))
+
+	assert.NoError(t, stream.Truncate(3))
+	for _, index := range []uint64{0, 1, 2, 4} {
+		_, err := store.Get(index)
+		assert.NoError(t, err, "node %d", index)
+	}
+	for _, index := range []uint64{3, 5, 6, 8, 9, 10, 12, 14} {
+		_, err := store.Get(index)
+		assert.Equal(t, merkle.ErrMissingNode, err, "node %d", index)
+	}
+
+	assert.NoError(t, store.Flush())
+	_, err = store.Get(14)
+	assert.Equal(t, merkle.ErrMissingNode, err)
+
+	assert.NoError(t, stream.Truncate(0))
+	length, err := tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), length)
+}
@@ -0,0 +1,126 @@
+package sleep
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TreeEntry_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	entry := TreeEntry{Hash: bytes.Repeat([]byte{0xab}, TreeHashSize), Size: 1025}
+	buf, err := entry.Encode()
+	assert.NoError(t, err)
+	assert.Len(t, buf, TreeEntrySize)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0x04, 0x01}, buf[TreeHashSize:])
+
+	decoded, err := DecodeTreeEntry(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, entry, decoded)
+	assert.False(t, decoded.IsBlank())
+}
+
+func Test_TreeEntry_Node(t *testing.T) {
+	t.Parallel()
+
+	node := merkle.NewNode(5, bytes.Repeat([]byte{0xcd}, TreeHashSize), 300)
+	entry := NewTreeEntry(node)
+	assert.Equal(t, TreeEntry{Hash: node.Hash(), Size: 300}, entry)
+	assert.Equal(t, node, entry.Node(5))
+}
+
+func Test_TreeEntry_InvalidHash(t *testing.T) {
+	t.Parallel()
+
+	_, err := TreeEntry{Hash: make([]byte, 64)}.Encode()
+	assert.Equal(t, ErrInvalidHashSize, err)
+
+	_, err = DecodeTreeEntry(make([]byte, 39))
+	assert.Error(t, err)
+}
+
+func Test_Tree(t *testing.T) {
+	t.Parallel()
+
+	mem := storage.NewMemory(0)
+	tree, err := OpenTree(mem, "BLAKE2b")
+	assert.NoError(t, err)
+
+	length, err := tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), length)
+
+	entry := TreeEntry{Hash: bytes.Repeat([]byte{1}, TreeHashSize), Size: 10}
+	assert.NoError(t, tree.Put(2, entry))
+
+	length, err = tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), length)
+
+	stat, err := mem.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(HeaderSize+3*TreeEntrySize), stat.Size)
+
+	got, err := tree.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	blank, err := tree.Get(0)
+	assert.NoError(t, err)
+	assert.True(t, blank.IsBlank())
+
+	_, err = tree.Get(3)
+	assert.Equal(t, storage.ErrOutOfBounds, err)
+
+	// reopening validates the existing header
+	tree, err = OpenTree(mem, "BLAKE2b")
+	assert.NoError(t, err)
+	got, err = tree.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	assert.NoError(t, tree.Del(0))
+	blank, err = tree.Get(0)
+	assert.NoError(t, err)
+	assert.True(t, blank.IsBlank())
+
+	assert.NoError(t, tree.PutBatch(3, []TreeEntry{entry, entry}))
+	length, err = tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), length)
+	got, err = tree.Get(4)
+	assert.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	assert.NoError(t, tree.Truncate(1))
+	length, err = tree.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), length)
+}
+
+func Test_Tree_HeaderMismatch(t *testing.T) {
+	t.Parallel()
+
+	mem := storage.NewMemory(0)
+	_, err := OpenSignatures(mem)
+	assert.NoError(t, err)
+
+	_, err = OpenTree(mem, "BLAKE2b")
+	assert.True(t, errors.Is(err, ErrHeaderMismatch))
+}
+
+func Test_Tree_AlgorithmMismatch(t *testing.T) {
+	t.Parallel()
+
+	mem := storage.NewMemory(0)
+	_, err := OpenTree(mem, "BLAKE2b")
+	assert.NoError(t, err)
+
+	_, err = OpenTree(mem, "SHA-256")
+	assert.True(t, errors.Is(err, ErrHeaderMismatch))
+}
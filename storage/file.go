@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// File is a storage backed by a file on disk
+type File struct {
+	file *os.File
+}
+
+// NewFile opens the file at path as a storage, creating it and its parent directories if they don't exist
+func NewFile(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{file: file}, nil
+}
+
+func (f *File) Read(offset, size uint64) ([]byte, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset+size > stat.Size {
+		return nil, ErrOutOfBounds
+	}
+
+	data := make([]byte, size)
+	if _, err := f.file.ReadAt(data, int64(offset)); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (f *File) Write(offset uint64, data []byte) error {
+	_, err := f.file.WriteAt(data, int64(offset))
+	return err
+}
+
+func (f *File) Del(offset, size uint64) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if offset >= stat.Size {
+		return nil
+	}
+	if offset+size >= stat.Size {
+		return f.Truncate(offset)
+	}
+
+	return f.Write(offset, make([]byte, size))
+}
+
+func (f *File) Truncate(size uint64) error {
+	return f.file.Truncate(int64(size))
+}
+
+func (f *File) Stat() (Stat, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return Stat{}, err
+	}
+
+	return Stat{Size: uint64(info.Size())}, nil
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_File(t *testing.T) {
+	t.Parallel()
+
+	file, err := NewFile(filepath.Join(t.TempDir(), "data"))
+	assert.NoError(t, err)
+
+	testStorage(t, file)
+}
+
+func Test_File_CreatesDirectories(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "feed", "tree")
+	file, err := NewFile(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
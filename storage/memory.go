@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/kiambogo/go-hypercore/mempager"
+)
+
+// Memory is a storage held in memory pages
+type Memory struct {
+	pager  *mempager.Pager
+	length uint64 // the length of the storage, in bytes
+	closed bool
+	mu     *sync.RWMutex
+}
+
+// NewMemory constructs an empty in-memory storage, with pages of the provided size
+// Defaults the page size to 1024 bytes if passed a size of 0
+func NewMemory(pageSize int) *Memory {
+	pgr := mempager.NewPager(pageSize)
+	return &Memory{
+		pager: &pgr,
+		mu:    &sync.RWMutex{},
+	}
+}
+
+func (m *Memory) Read(offset, size uint64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if offset+size > m.length {
+		return nil, ErrOutOfBounds
+	}
+
+	data := make([]byte, size)
+	for read := uint64(0); read < size; {
+		pageNum, pageOffset := m.locate(offset + read)
+		page := m.pager.Get(pageNum)
+		if page == nil {
+			// unallocated pages are implicitly zeroed
+			read += uint64(m.pager.PageSize()) - pageOffset
+			continue
+		}
+		read += uint64(copy(data[read:], (*page.Buffer())[pageOffset:]))
+	}
+
+	return data, nil
+}
+
+func (m *Memory) Write(offset uint64, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	for written := 0; written < len(data); {
+		pageNum, pageOffset := m.locate(offset + uint64(written))
+		page := m.pager.GetOrAlloc(pageNum)
+		written += copy((*page.Buffer())[pageOffset:], data[written:])
+	}
+
+	if end := offset + uint64(len(data)); end > m.length {
+		m.length = end
+	}
+
+	return nil
+}
+
+func (m *Memory) Del(offset, size uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+	if offset >= m.length {
+		return nil
+	}
+	if offset+size >= m.length {
+		m.zero(offset, m.length)
+		m.length = offset
+		return nil
+	}
+
+	m.zero(offset, offset+size)
+	return nil
+}
+
+func (m *Memory) Truncate(size uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+	if size < m.length {
+		m.zero(size, m.length)
+	}
+	m.length = size
+
+	return nil
+}
+
+func (m *Memory) Stat() (Stat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return Stat{}, ErrClosed
+	}
+	return Stat{Size: m.length}, nil
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	return nil
+}
+
+// zero clears the bytes in the range [start, end) of any allocated pages
+func (m *Memory) zero(start, end uint64) {
+	for start < end {
+		pageNum, pageOffset := m.locate(start)
+		n := uint64(m.pager.PageSize()) - pageOffset
+		if n > end-start {
+			n = end - start
+		}
+		if page := m.pager.Get(pageNum); page != nil {
+			buf := (*page.Buffer())[pageOffset : pageOffset+n]
+			for i := range buf {
+				buf[i] = 0
+			}
+		}
+		start += n
+	}
+}
+
+// locate returns the page number and the offset within that page of a byte offset
+func (m Memory) locate(offset uint64) (int, uint64) {
+	pageSize := uint64(m.pager.PageSize())
+	return int(offset / pageSize), offset % pageSize
+}
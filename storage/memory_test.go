@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Memory(t *testing.T) {
+	t.Parallel()
+
+	testStorage(t, NewMemory(0))
+}
+
+func Test_Memory_SmallPages(t *testing.T) {
+	t.Parallel()
+
+	testStorage(t, NewMemory(3))
+}
+
+func Test_Memory_WriteAcrossPages(t *testing.T) {
+	t.Parallel()
+
+	mem := NewMemory(4)
+	data := bytes.Repeat([]byte("abc"), 10)
+	assert.NoError(t, mem.Write(2, data))
+	assert.Equal(t, 8, mem.pager.Len())
+
+	read, err := mem.Read(2, uint64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, data, read)
+}
+
+func Test_Memory_Closed(t *testing.T) {
+	t.Parallel()
+
+	mem := NewMemory(0)
+	assert.NoError(t, mem.Close())
+
+	_, err := mem.Read(0, 0)
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, mem.Write(0, []byte("hello")))
+	_, err = mem.Stat()
+	assert.Equal(t, ErrClosed, err)
+}
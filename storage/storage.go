@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+var (
+	ErrOutOfBounds = errors.New("read out of bounds")
+	ErrClosed      = errors.New("storage is closed")
+)
+
+// Storage is a random access interface over a single addressable store of bytes
+// Modeled on https://github.com/random-access-storage/random-access-storage
+type Storage interface {
+	// Read returns size bytes starting at offset, erroring if the range exceeds the stored length
+	Read(offset, size uint64) ([]byte, error)
+	// Write stores data at offset, growing the storage if required
+	Write(offset uint64, data []byte) error
+	// Del clears size bytes starting at offset; clearing through the end of the storage shortens it
+	Del(offset, size uint64) error
+	// Truncate sets the length of the storage, discarding any data past size
+	Truncate(size uint64) error
+	// Stat returns information about the storage
+	Stat() (Stat, error)
+	// Close releases the storage, after which it can no longer be used
+	Close() error
+}
+
+// Stat describes a storage
+type Stat struct {
+	Size uint64 // the length of the storage, in bytes
+}
+
+// Provider opens the storage for a named file of a feed, ie. "data" or "tree"
+type Provider func(name string) (Storage, error)
+
+// MemoryProvider returns a provider which opens a new in-memory storage for every name
+func MemoryProvider() Provider {
+	return func(name string) (Storage, error) {
+		return NewMemory(0), nil
+	}
+}
+
+// DirProvider returns a provider which opens files of the given names within dir
+func DirProvider(dir string) Provider {
+	return func(name string) (Storage, error) {
+		return NewFile(filepath.Join(dir, name))
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := MemoryProvider()
+	data, err := provider("data")
+	assert.NoError(t, err)
+	tree, err := provider("tree")
+	assert.NoError(t, err)
+
+	assert.NoError(t, data.Write(0, []byte("hello")))
+	stat, err := tree.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), stat.Size, "storages opened by a provider should be independent")
+}
+
+func Test_DirProvider(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data, err := DirProvider(dir)("data")
+	assert.NoError(t, err)
+	assert.NoError(t, data.Write(0, []byte("hello")))
+	assert.NoError(t, data.Close())
+
+	data, err = DirProvider(dir)("data")
+	assert.NoError(t, err)
+	defer data.Close()
+
+	read, err := data.Read(0, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), read)
+}
+
+// testStorage exercises the behaviour common to every storage implementation
+func testStorage(t *testing.T, s Storage) {
+	stat, err := s.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), stat.Size)
+
+	_, err = s.Read(0, 1)
+	assert.Equal(t, ErrOutOfBounds, err)
+
+	assert.NoError(t, s.Write(0, []byte("hello")))
+	assert.NoError(t, s.Write(10, []byte("world")))
+
+	stat, err = s.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(15), stat.Size)
+
+	data, err := s.Read(0, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello\x00\x00\x00\x00\x00world"), data)
+
+	data, err = s.Read(3, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("lo\x00\x00\x00\x00\x00wo"), data)
+
+	_, err = s.Read(10, 6)
+	assert.Equal(t, ErrOutOfBounds, err)
+
+	// deleting within the storage zeroes the range
+	assert.NoError(t, s.Del(1, 2))
+	data, err = s.Read(0, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("h\x00\x00lo"), data)
+
+	// deleting through the end of the storage shortens it
+	assert.NoError(t, s.Del(12, 10))
+	stat, err = s.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), stat.Size)
+
+	assert.NoError(t, s.Truncate(4))
+	stat, err = s.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), stat.Size)
+
+	// growing the storage exposes zeroes, not the previously truncated data
+	assert.NoError(t, s.Truncate(8))
+	data, err = s.Read(0, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("h\x00\x00l\x00\x00\x00\x00"), data)
+
+	assert.NoError(t, s.Close())
+}
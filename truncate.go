@@ -0,0 +1,100 @@
+package hypercore
+
+import (
+	"encoding/binary"
+
+	"github.com/kiambogo/go-hypercore/storage"
+)
+
+// Truncate drops all blocks at or after the provided length from the feed
+// Truncating rewrites the history of the feed, so the fork counter is incremented
+// and the roots of the remaining tree are signed again
+func (f *Feed) Truncate(length uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	if !f.keyPair.CanSign() {
+		return ErrNotWritable
+	}
+	if length > f.stream.Blocks() {
+		return ErrOutOfBounds
+	}
+	if length == f.stream.Blocks() {
+		return nil
+	}
+
+	offset, err := f.dataOffset(length)
+	if err != nil {
+		return err
+	}
+	if err := f.data.Truncate(offset); err != nil {
+		return err
+	}
+
+	if err := f.signatures.Truncate(length); err != nil {
+		return err
+	}
+
+	for i := length; i < f.bitfield.Len(); i++ {
+		f.bitfield.SetBit(int(i), false)
+	}
+	// the ancestors of the first removed leaf are cleared too, and may precede it in earlier pages
+	firstNode := f.lowestSetAncestor(length * 2)
+	f.tree.Truncate(length * 2)
+	if err := f.storeBitfields(length, firstNode); err != nil {
+		return err
+	}
+
+	// the stream drops the removed nodes from the tree file, and reads back the roots of the remaining tree
+	if err := f.stream.Truncate(length); err != nil {
+		return err
+	}
+
+	f.fork++
+	if err := writeFork(f.forkStore, f.fork); err != nil {
+		return err
+	}
+
+	if length == 0 {
+		return nil
+	}
+
+	signature, err := f.keyPair.Sign(f.stream.TreeHash())
+	if err != nil {
+		return err
+	}
+
+	return f.signatures.Put(length-1, signature)
+}
+
+// Fork returns the number of times the feed has been truncated
+func (f *Feed) Fork() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.fork
+}
+
+// the fork counter is stored as a raw big endian uint64, without a SLEEP header
+func readFork(s storage.Storage) (uint64, error) {
+	stat, err := s.Stat()
+	if err != nil || stat.Size == 0 {
+		return 0, err
+	}
+
+	buf, err := s.Read(0, 8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func writeFork(s storage.Storage, fork uint64) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, fork)
+	return s.Write(0, buf)
+}
@@ -0,0 +1,132 @@
+package hypercore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/sleep"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_Truncate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint(i * 11)))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, ErrOutOfBounds, feed.Truncate(8))
+	assert.NoError(t, feed.Truncate(7))
+	assert.Equal(t, uint64(0), feed.Fork())
+
+	assert.NoError(t, feed.Truncate(3))
+	assert.Equal(t, uint64(1), feed.Fork())
+	assert.Equal(t, uint64(3), feed.Len())
+	assert.Equal(t, uint64(5), feed.ByteLength())
+	assert.False(t, feed.Has(3))
+	assert.False(t, feed.tree.Get(3))
+	assert.True(t, feed.tree.Get(1))
+	assert.Equal(t, uint64(3), feed.ContiguousLength())
+
+	_, err = feed.Get(3)
+	assert.Equal(t, ErrOutOfBounds, err)
+	data, err := feed.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("22"), data)
+
+	expected, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = expected.Append([]byte("0"), []byte("11"), []byte("22"))
+	assert.NoError(t, err)
+	assertSameRoots(t, expected, feed)
+
+	signature, err := feed.Signature(2)
+	assert.NoError(t, err)
+	assert.True(t, feed.VerifyRoots(feed.Roots(), signature))
+
+	// the truncated state is re-signed as a bare tree hash, as in hypercore v9 signatures files
+	assert.True(t, crypto.Verify(feed.PublicKey(), merkle.BLAKE2b256{}.HashRoots(feed.Roots()), signature))
+
+	proof, err := feed.Proof(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), proof.Fork)
+	assert.NoError(t, Verify(proof, []byte("11"), feed.PublicKey()))
+
+	// appending after a truncation builds on the truncated tree
+	_, err = feed.Append([]byte("fork"))
+	assert.NoError(t, err)
+	_, err = expected.Append([]byte("fork"))
+	assert.NoError(t, err)
+	assertSameRoots(t, expected, feed)
+	assert.NoError(t, feed.Close())
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), feed.Fork())
+	assert.Equal(t, uint64(4), feed.Len())
+	assertSameRoots(t, expected, feed)
+
+	data, err = feed.Get(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("fork"), data)
+
+	assert.NoError(t, feed.Truncate(0))
+	assert.Equal(t, uint64(2), feed.Fork())
+	assert.Equal(t, uint64(0), feed.Len())
+	assert.Equal(t, uint64(0), feed.ByteLength())
+	assert.Empty(t, feed.Roots())
+}
+
+func Test_Feed_TruncateReadOnly(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("hello"))
+	assert.NoError(t, err)
+
+	feed.keyPair.SecretKey = nil
+	assert.Equal(t, ErrNotWritable, feed.Truncate(0))
+	assert.Equal(t, uint64(1), feed.Len())
+}
+
+func assertSameRoots(t *testing.T, expected, actual *Feed) {
+	expectedRoots := expected.Roots()
+	actualRoots := actual.Roots()
+	assert.Len(t, actualRoots, len(expectedRoots))
+	for i := range expectedRoots {
+		assert.Equal(t, expectedRoots[i].Index(), actualRoots[i].Index())
+		assert.Equal(t, expectedRoots[i].Hash(), actualRoots[i].Hash())
+		assert.Equal(t, expectedRoots[i].Size(), actualRoots[i].Size())
+	}
+}
+
+func Test_Feed_ReopenAfterTruncateAcrossBitfieldPages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	_, err = feed.Append(manyBlocks(2 * sleep.DataPageSize * 8)...)
+	assert.NoError(t, err)
+
+	// truncating clears node 16383, an ancestor of the first removed leaf stored in the first page
+	assert.NoError(t, feed.Truncate(8193))
+	assert.False(t, feed.tree.Get(16383))
+	assert.NoError(t, feed.Close())
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8193), feed.Len())
+	assert.Equal(t, uint64(8193), feed.ContiguousLength())
+	assert.False(t, feed.tree.Get(16383))
+	assert.True(t, feed.tree.Get(8191))
+	assert.NoError(t, feed.Close())
+}
@@ -0,0 +1,129 @@
+package hypercore
+
+import (
+	"errors"
+
+	"github.com/kiambogo/go-hypercore/crypto"
+	"github.com/kiambogo/go-hypercore/flattree"
+	"github.com/kiambogo/go-hypercore/merkle"
+)
+
+var ErrInvalidUpgrade = errors.New("upgrade must be to a length no shorter than the current length")
+
+// UpgradeProof proves that the tree with a length of To extends the tree with a length of From
+type UpgradeProof struct {
+	From      uint64        // length of the tree the reader already trusts
+	To        uint64        // length of the upgraded tree
+	Fork      uint64        // fork of the feed when the proof was built
+	Algorithm string        // name of the algorithm hashing the nodes, as given by merkle.NodeHasher Algorithm
+	Nodes     []merkle.Node // the nodes which, with the roots at From, rebuild the roots at To
+	Signature []byte        // signature of the upgraded tree
+}
+
+// UpgradeProof builds the proof that the feed at the length to extends the feed at the length from
+// The length to must be the current length of the feed: lengths within an append batch were never signed,
+// and earlier signatures may have been made under a fork the feed has since truncated away
+func (f *Feed) UpgradeProof(from, to uint64) (UpgradeProof, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return UpgradeProof{}, ErrClosed
+	}
+	if from > to {
+		return UpgradeProof{}, ErrInvalidUpgrade
+	}
+	if to == 0 || to > f.stream.Blocks() {
+		return UpgradeProof{}, ErrOutOfBounds
+	}
+	if to != f.stream.Blocks() {
+		return UpgradeProof{}, ErrUnsignedLength
+	}
+
+	fromRoots, err := flattree.FullRoots(from * 2)
+	if err != nil {
+		return UpgradeProof{}, err
+	}
+	toRoots, err := flattree.FullRoots(to * 2)
+	if err != nil {
+		return UpgradeProof{}, err
+	}
+
+	trusted := map[uint64]bool{}
+	for _, root := range fromRoots {
+		trusted[root] = true
+	}
+	isTrusted := func(index uint64) bool { return trusted[index] }
+	isNew := func(index uint64) bool { return flattree.LeftSpan(index) >= from*2 }
+
+	proof := UpgradeProof{From: from, To: to, Fork: f.fork, Algorithm: f.hasher.Algorithm()}
+	if proof.Nodes, err = f.proofNodes(toRoots, isTrusted, isNew); err != nil {
+		return UpgradeProof{}, err
+	}
+
+	if proof.Signature, err = f.signatures.Get(to - 1); err != nil {
+		return UpgradeProof{}, err
+	}
+
+	return proof, nil
+}
+
+// VerifyUpgrade checks that the upgraded tree of the proof extends the tree formed by the trusted roots,
+// and that the upgraded tree is signed by the public key, for the proofs of BLAKE2b256 feeds
+// Returns the roots of the upgraded tree
+func VerifyUpgrade(proof UpgradeProof, fromRoots []merkle.Node, publicKey []byte) ([]merkle.Node, error) {
+	return verifyUpgrade(merkle.BLAKE2b256{}, proof, fromRoots, publicKey)
+}
+
+func verifyUpgrade(hasher merkle.NodeHasher, proof UpgradeProof, fromRoots []merkle.Node, publicKey []byte) ([]merkle.Node, error) {
+	if proof.Algorithm != hasher.Algorithm() {
+		return nil, ErrAlgorithmMismatch
+	}
+	if proof.From > proof.To {
+		return nil, ErrInvalidUpgrade
+	}
+
+	expected, err := flattree.FullRoots(proof.From * 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) != len(fromRoots) {
+		return nil, merkle.ErrInvalidRoots
+	}
+
+	known := map[uint64]merkle.Node{}
+	for i, root := range fromRoots {
+		if root.Index() != expected[i] {
+			return nil, merkle.ErrInvalidRoots
+		}
+		known[root.Index()] = root
+	}
+	for _, node := range proof.Nodes {
+		// proof nodes must lie wholly beyond the trusted tree, otherwise they could stand in for
+		// ancestors of the trusted roots and skip proving that the upgraded tree extends them
+		if flattree.LeftSpan(node.Index()) < proof.From*2 {
+			return nil, ErrInvalidProof
+		}
+		known[node.Index()] = node
+	}
+
+	toRoots, err := flattree.FullRoots(proof.To * 2)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []merkle.Node{}
+	for _, index := range toRoots {
+		root, err := rebuildNode(hasher, index, known)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+
+	if !crypto.Verify(publicKey, hasher.HashRoots(roots), proof.Signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	return roots, nil
+}
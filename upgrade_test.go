@@ -0,0 +1,129 @@
+package hypercore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_UpgradeProof(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	rootsAt := [][]merkle.Node{{}}
+	for length := 1; length <= 12; length++ {
+		_, err = feed.Append([]byte(fmt.Sprint("block ", length)))
+		assert.NoError(t, err)
+		rootsAt = append(rootsAt, feed.Roots())
+	}
+
+	to := uint64(12)
+	for from := uint64(0); from <= to; from++ {
+		proof, err := feed.UpgradeProof(from, to)
+		assert.NoError(t, err)
+
+		roots, err := VerifyUpgrade(proof, rootsAt[from], feed.PublicKey())
+		assert.NoError(t, err, "upgrade from %d", from)
+		assert.Equal(t, len(rootsAt[to]), len(roots))
+		for i := range roots {
+			assert.Equal(t, rootsAt[to][i].Hash(), roots[i].Hash())
+		}
+	}
+}
+
+func Test_Feed_UpgradeProofNodes(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		_, err = feed.Append([]byte(fmt.Sprint(i)))
+		assert.NoError(t, err)
+	}
+
+	// roots [1, 4] at a length of 3 upgrade to roots [3, 9] at a length of 6
+	proof, err := feed.UpgradeProof(3, 6)
+	assert.NoError(t, err)
+
+	indices := []uint64{}
+	for _, node := range proof.Nodes {
+		indices = append(indices, node.Index())
+	}
+	assert.Equal(t, []uint64{6, 9}, indices)
+
+	_, err = feed.UpgradeProof(4, 3)
+	assert.Equal(t, ErrInvalidUpgrade, err)
+	_, err = feed.UpgradeProof(3, 7)
+	assert.Equal(t, ErrOutOfBounds, err)
+}
+
+func Test_VerifyUpgrade_DetectsFork(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"))
+	assert.NoError(t, err)
+	trusted := feed.Roots()
+
+	// the writer rewrites history after the reader trusted a length of 3
+	assert.NoError(t, feed.Truncate(2))
+	_, err = feed.Append([]byte("x"), []byte("d"))
+	assert.NoError(t, err)
+
+	proof, err := feed.UpgradeProof(3, 4)
+	assert.NoError(t, err)
+	_, err = VerifyUpgrade(proof, trusted, feed.PublicKey())
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	// the roots of the rewritten history do verify
+	rewritten, err := feed.UpgradeProof(0, 4)
+	assert.NoError(t, err)
+	_, err = VerifyUpgrade(rewritten, nil, feed.PublicKey())
+	assert.NoError(t, err)
+
+	_, err = VerifyUpgrade(proof, trusted[:1], feed.PublicKey())
+	assert.Equal(t, merkle.ErrInvalidRoots, err)
+
+	// substituting the upgraded roots for the proof nodes must not bypass the trusted roots
+	substituted := proof
+	substituted.Nodes = feed.Roots()
+	_, err = VerifyUpgrade(substituted, trusted, feed.PublicKey())
+	assert.Equal(t, ErrInvalidProof, err)
+
+	incomplete := proof
+	incomplete.Nodes = nil
+	_, err = VerifyUpgrade(incomplete, trusted, feed.PublicKey())
+	assert.Equal(t, ErrInvalidProof, err)
+}
+
+func Test_Feed_UpgradeProofUnsignedLength(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{})
+	assert.NoError(t, err)
+
+	// only the last length of an append batch is signed
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"))
+	assert.NoError(t, err)
+	_, err = feed.UpgradeProof(0, 2)
+	assert.Equal(t, ErrUnsignedLength, err)
+
+	// the length of 3 was signed before the truncation, under the old fork
+	assert.NoError(t, feed.Truncate(2))
+	trusted := feed.Roots()
+	_, err = feed.Append([]byte("x"), []byte("y"))
+	assert.NoError(t, err)
+	_, err = feed.UpgradeProof(0, 3)
+	assert.Equal(t, ErrUnsignedLength, err)
+
+	proof, err := feed.UpgradeProof(2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, feed.Fork(), proof.Fork)
+	_, err = VerifyUpgrade(proof, trusted, feed.PublicKey())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,38 @@
+package hypercore
+
+import "github.com/kiambogo/go-hypercore/merkle"
+
+// Verifier checks the proofs of a feed hashed by a particular hasher and signed by a particular key
+// Proofs recording a different hashing algorithm than the hasher are rejected with ErrAlgorithmMismatch
+// The package level Verify, VerifyWith, VerifyRange and VerifyUpgrade only check proofs hashed by BLAKE2b256,
+// so a Verifier is needed for feeds with any other hasher
+type Verifier struct {
+	Hasher    merkle.NodeHasher
+	PublicKey []byte
+}
+
+// NewVerifier constructs a verifier of the proofs of the feed with the provided hasher and public key
+func NewVerifier(hasher merkle.NodeHasher, publicKey []byte) Verifier {
+	return Verifier{Hasher: hasher, PublicKey: publicKey}
+}
+
+// Verify checks that the block is proven by the signed roots of the proof
+func (v Verifier) Verify(proof Proof, block []byte) error {
+	return verify(v.Hasher, proof, block, v.PublicKey)
+}
+
+// VerifyWith checks that the block is proven by the proof, given the nodes the verifier already trusts
+func (v Verifier) VerifyWith(proof Proof, block []byte, trusted []merkle.Node) error {
+	return verifyWith(v.Hasher, proof, block, v.PublicKey, trusted)
+}
+
+// VerifyRange checks that the blocks are proven by the signed roots of the range proof
+func (v Verifier) VerifyRange(proof RangeProof, blocks [][]byte) error {
+	return verifyRange(v.Hasher, proof, blocks, v.PublicKey)
+}
+
+// VerifyUpgrade checks that the upgraded tree of the proof extends the tree formed by the trusted roots
+// Returns the roots of the upgraded tree
+func (v Verifier) VerifyUpgrade(proof UpgradeProof, fromRoots []merkle.Node) ([]merkle.Node, error) {
+	return verifyUpgrade(v.Hasher, proof, fromRoots, v.PublicKey)
+}
@@ -0,0 +1,103 @@
+package hypercore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kiambogo/go-hypercore/merkle"
+	"github.com/kiambogo/go-hypercore/sleep"
+	"github.com/kiambogo/go-hypercore/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verifier_Hashers(t *testing.T) {
+	t.Parallel()
+
+	keyed, err := merkle.NewKeyedBLAKE2b256([]byte("secret"))
+	assert.NoError(t, err)
+
+	for _, hasher := range []merkle.NodeHasher{merkle.BLAKE2b256{}, merkle.SHA256{}, keyed} {
+		feed, err := NewFeed(Options{Hasher: hasher})
+		assert.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			_, err = feed.Append([]byte(fmt.Sprint("block ", i)))
+			assert.NoError(t, err)
+		}
+		verifier := NewVerifier(hasher, feed.PublicKey())
+
+		proof, err := feed.Proof(3, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, hasher.Algorithm(), proof.Algorithm)
+		assert.NoError(t, verifier.Verify(proof, []byte("block 3")), hasher.Algorithm())
+
+		rangeProof, err := feed.RangeProof(1, 4)
+		assert.NoError(t, err)
+		assert.NoError(t, verifier.VerifyRange(rangeProof, [][]byte{[]byte("block 1"), []byte("block 2"), []byte("block 3")}), hasher.Algorithm())
+
+		upgrade, err := feed.UpgradeProof(0, 5)
+		assert.NoError(t, err)
+		roots, err := verifier.VerifyUpgrade(upgrade, nil)
+		assert.NoError(t, err, hasher.Algorithm())
+		assert.Len(t, roots, 2)
+	}
+}
+
+func Test_Verifier_AlgorithmMismatch(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewFeed(Options{Hasher: merkle.SHA256{}})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("a"), []byte("b"), []byte("c"))
+	assert.NoError(t, err)
+
+	proof, err := feed.Proof(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrAlgorithmMismatch, Verify(proof, []byte("b"), feed.PublicKey()))
+
+	rangeProof, err := feed.RangeProof(0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrAlgorithmMismatch, VerifyRange(rangeProof, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, feed.PublicKey()))
+
+	upgrade, err := feed.UpgradeProof(0, 3)
+	assert.NoError(t, err)
+	_, err = VerifyUpgrade(upgrade, nil, feed.PublicKey())
+	assert.Equal(t, ErrAlgorithmMismatch, err)
+
+	// relabelling the proof does not help, as the nodes were hashed by SHA-256
+	proof.Algorithm = merkle.BLAKE2b256{}.Algorithm()
+	assert.Equal(t, ErrInvalidSignature, Verify(proof, []byte("b"), feed.PublicKey()))
+}
+
+func Test_NewFeed_InvalidHashSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := NewFeed(Options{Storage: storage.DirProvider(dir), Hasher: merkle.BLAKE2b512{}})
+	assert.Equal(t, merkle.ErrInvalidHashSize, err)
+
+	// nothing is written, so the feed can still be created with a supported hasher
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("hello"))
+	assert.NoError(t, err)
+	assert.NoError(t, feed.Close())
+}
+
+func Test_Feed_HasherMismatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := NewFeed(Options{Storage: storage.DirProvider(dir), Hasher: merkle.SHA256{}})
+	assert.NoError(t, err)
+	_, err = feed.Append([]byte("hello"))
+	assert.NoError(t, err)
+	assert.NoError(t, feed.Close())
+
+	_, err = NewFeed(Options{Storage: storage.DirProvider(dir)})
+	assert.True(t, errors.Is(err, sleep.ErrHeaderMismatch))
+
+	feed, err = NewFeed(Options{Storage: storage.DirProvider(dir), Hasher: merkle.SHA256{}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), feed.Len())
+}